@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"notemind/internal/digest"
+	"notemind/internal/note"
+)
+
+const commandUsage = `usage:
+  notemind                                   start the API server
+  notemind digest run                        send every digest that is due now
+  notemind digest preview [-format text|html] <user_id>
+                                             print a user's digest without sending it
+  notemind notes resummarize [-limit n]      regenerate summaries written with an outdated prompt`
+
+// runCommand runs a one-off command instead of the server, for cron jobs and
+// operators on the box.
+func runCommand(ctx context.Context, args []string, digestService digest.DigestService, noteService note.NoteService) error {
+	if len(args) < 2 {
+		return errors.New(commandUsage)
+	}
+	switch args[0] {
+	case "digest":
+		return runDigestCommand(ctx, args[1:], digestService)
+	case "notes":
+		return runNotesCommand(ctx, args[1:], noteService)
+	default:
+		return errors.New(commandUsage)
+	}
+}
+
+func runNotesCommand(ctx context.Context, args []string, noteService note.NoteService) error {
+	if args[0] != "resummarize" {
+		return errors.New(commandUsage)
+	}
+	fs := flag.NewFlagSet("notes resummarize", flag.ContinueOnError)
+	limit := fs.Int("limit", 100, "maximum number of notes to re-summarize")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *limit < 1 {
+		return fmt.Errorf("invalid limit %d", *limit)
+	}
+	report, err := noteService.ResummarizeStale(ctx, *limit)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
+
+func runDigestCommand(ctx context.Context, args []string, digestService digest.DigestService) error {
+	switch args[0] {
+	case "run":
+		report, err := digestService.Run(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(report)
+	case "preview":
+		fs := flag.NewFlagSet("digest preview", flag.ContinueOnError)
+		format := fs.String("format", "text", "text or html")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return errors.New(commandUsage)
+		}
+		userID, err := strconv.ParseUint(fs.Arg(0), 10, 64)
+		if err != nil || userID == 0 {
+			return fmt.Errorf("invalid user id %q", fs.Arg(0))
+		}
+		msg, err := digestService.Preview(ctx, uint(userID))
+		if err != nil {
+			return err
+		}
+		if *format == "html" {
+			fmt.Println(msg.HTML)
+			return nil
+		}
+		fmt.Printf("Subject: %s\n\n%s\n", msg.Subject, msg.Text)
+		return nil
+	default:
+		return errors.New(commandUsage)
+	}
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
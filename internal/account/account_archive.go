@@ -0,0 +1,187 @@
+package account
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"notemind/internal/auth"
+	"notemind/internal/media"
+	"notemind/internal/note"
+)
+
+// maxImageBytes caps a single downloaded image so a bad URL cannot fill the disk.
+const maxImageBytes = 25 << 20
+
+type archive struct {
+	ExportedAt time.Time      `json:"exported_at"`
+	Profile    archiveProfile `json:"profile"`
+	Notes      []archiveNote  `json:"notes"`
+}
+
+type archiveProfile struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Timezone  string    `json:"timezone"`
+	Birthday  *string   `json:"birthday"`
+	Gender    string    `json:"gender"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type archiveNote struct {
+	ID        uint           `json:"id"`
+	Title     string         `json:"title"`
+	Content   string         `json:"content"`
+	Format    string         `json:"content_format"`
+	Summary   string         `json:"summary"`
+	Markdown  string         `json:"markdown_file"`
+	Images    []archiveImage `json:"images"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type archiveImage struct {
+	ID            uint      `json:"id"`
+	URL           string    `json:"url"`
+	File          string    `json:"file,omitempty"`
+	DownloadError string    `json:"download_error,omitempty"`
+	UploadedAt    time.Time `json:"uploaded_at"`
+}
+
+// writeArchive builds the ZIP for an export: export.json with everything,
+// one Markdown file per note and the note images under media/.
+func (s *accountService) writeArchive(export *DataExport) (string, error) {
+	user, err := s.repo.GetUser(export.UserID)
+	if err != nil {
+		return "", fmt.Errorf("load user: %w", err)
+	}
+	notes, err := s.repo.GetNotes(export.UserID)
+	if err != nil {
+		return "", fmt.Errorf("load notes: %w", err)
+	}
+
+	dir := exportDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	filePath := filepath.Join(dir, fmt.Sprintf("export-%d-%s.zip", export.ID, hex.EncodeToString(suffix)))
+
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", err
+	}
+	zw := zip.NewWriter(f)
+
+	err = writeArchiveEntries(zw, user, notes)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return "", err
+	}
+	return filePath, nil
+}
+
+func writeArchiveEntries(zw *zip.Writer, user *auth.User, notes []note.Note) error {
+	doc := archive{
+		ExportedAt: time.Now().UTC(),
+		Profile: archiveProfile{
+			ID:        user.ID,
+			Name:      user.Name,
+			Email:     user.Email,
+			Timezone:  user.Timezone,
+			Gender:    user.Gender,
+			CreatedAt: user.CreatedAt,
+		},
+		Notes: make([]archiveNote, 0, len(notes)),
+	}
+	if user.Birthday != nil {
+		birthday := user.Birthday.Format("2006-01-02")
+		doc.Profile.Birthday = &birthday
+	}
+
+	for i := range notes {
+		n := &notes[i]
+		mdPath := "notes/" + n.FileName() + ".md"
+		if err := writeZipFile(zw, mdPath, []byte(n.Markdown())); err != nil {
+			return err
+		}
+
+		entry := archiveNote{
+			ID:        n.ID,
+			Title:     n.Title,
+			Content:   n.Content,
+			Format:    n.ContentFormat,
+			Summary:   n.Summary,
+			Markdown:  mdPath,
+			Images:    make([]archiveImage, 0, len(n.Images)),
+			CreatedAt: n.CreatedAt,
+			UpdatedAt: n.UpdatedAt,
+		}
+		for _, img := range n.Images {
+			image := archiveImage{ID: img.ID, URL: img.ImageURL, UploadedAt: img.UploadedAt}
+			name, err := copyImage(zw, n.ID, img)
+			if err != nil {
+				image.DownloadError = err.Error()
+			} else {
+				image.File = name
+			}
+			entry.Images = append(entry.Images, image)
+		}
+		doc.Notes = append(doc.Notes, entry)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(zw, "export.json", data)
+}
+
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func copyImage(zw *zip.Writer, noteID uint, img note.NoteImage) (string, error) {
+	body, contentType, err := media.Download(context.Background(), img.ImageURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	name := fmt.Sprintf("media/%d/%d%s", noteID, img.ID, media.FileExt(img.ImageURL, contentType))
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(w, io.LimitReader(body, maxImageBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if n > maxImageBytes {
+		return "", fmt.Errorf("image larger than %d bytes", maxImageBytes)
+	}
+	return name, nil
+}
@@ -0,0 +1,130 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountHandler struct {
+	accountService AccountService
+}
+
+func NewAccountHandler(accountService AccountService) *AccountHandler {
+	return &AccountHandler{accountService: accountService}
+}
+
+func (h *AccountHandler) DeleteAccount(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.accountService.RequestDeletion(user.UserID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Your account and all of its data are scheduled for permanent deletion",
+	})
+}
+
+func (h *AccountHandler) RequestExport(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	export, err := h.accountService.RequestExport(user.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrExportInProgress):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "export": export})
+		case errors.Is(err, ErrDeletionPending):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":    "Export started",
+		"export":     export,
+		"status_url": fmt.Sprintf("/api/v1/me/export/%d", export.ID),
+	})
+}
+
+func (h *AccountHandler) GetExport(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	exportID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || exportID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	export, err := h.accountService.GetExport(user.UserID, uint(exportID))
+	if err != nil {
+		if errors.Is(err, ErrExportNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+
+	res := gin.H{"export": export}
+	if export.Status == ExportReady {
+		link, expires, err := h.accountService.DownloadURL(export)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+			return
+		}
+		res["download_url"] = link
+		res["download_url_expires_at"] = expires.UTC()
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DownloadExport sends the holder of a signed link, no login needed, on to
+// the archive in the archive store.
+func (h *AccountHandler) DownloadExport(ctx *gin.Context) {
+	exportID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+	expires, err := strconv.ParseInt(ctx.Query("expires"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": ErrInvalidDownload.Error()})
+		return
+	}
+
+	archiveURL, err := h.accountService.OpenDownload(uint(exportID), expires, ctx.Query("signature"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidDownload) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+
+	// the archive store's link is signed too, it must not be cached or leak
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Referrer-Policy", "no-referrer")
+	ctx.Redirect(http.StatusFound, archiveURL)
+}
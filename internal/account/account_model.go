@@ -0,0 +1,31 @@
+package account
+
+import "time"
+
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportReady      = "ready"
+	ExportFailed     = "failed"
+	ExportExpired    = "expired"
+)
+
+// DataExport is a ZIP archive of everything stored for a user, built in the
+// background and downloadable through a signed, time-limited link until
+// ExpiresAt, when the archive is removed. Archives are kept in the archive
+// store so that any replica can serve and remove them.
+type DataExport struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id"`
+	Status      string     `json:"status"`
+	ArchiveID   string     `json:"-"`
+	Error       string     `json:"error,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+
+	// Worker is the replica building the export, which keeps HeartbeatAt
+	// current while it does.
+	Worker      string     `json:"-"`
+	HeartbeatAt *time.Time `json:"-"`
+}
@@ -0,0 +1,161 @@
+package account
+
+import (
+	"time"
+
+	"notemind/internal/auth"
+	"notemind/internal/note"
+
+	"gorm.io/gorm"
+)
+
+type AccountRepo interface {
+	MarkDeletionRequested(userID uint, at time.Time) error
+	ListUsersPendingDeletion(requestedBefore time.Time) ([]uint, error)
+	ListImages(userID uint) ([]note.NoteImage, error)
+	ListExports(userID uint) ([]DataExport, error)
+	PurgeUser(userID uint) error
+
+	GetUser(userID uint) (*auth.User, error)
+	GetNotes(userID uint) ([]note.Note, error)
+
+	CreateExport(export *DataExport) error
+	UpdateExport(export *DataExport) error
+	GetExport(userID, exportID uint) (*DataExport, error)
+	GetExportByID(exportID uint) (*DataExport, error)
+	GetActiveExport(userID uint) (*DataExport, error)
+	ListExpiredExports(now time.Time) ([]DataExport, error)
+	TouchExport(exportID uint, at time.Time) error
+	// FailInterruptedExports marks the unfinished exports of worker, which
+	// is not building them anymore, or when worker is "" those whose
+	// heartbeat stopped before staleBefore.
+	FailInterruptedExports(worker string, staleBefore time.Time) error
+}
+
+type accountRepo struct {
+	db *gorm.DB
+}
+
+func NewAccountRepo(db *gorm.DB) AccountRepo {
+	return &accountRepo{db: db}
+}
+
+func (r *accountRepo) MarkDeletionRequested(userID uint, at time.Time) error {
+	return r.db.Model(&auth.User{}).
+		Where("id = ? AND deletion_requested_at IS NULL", userID).
+		Update("deletion_requested_at", at).Error
+}
+
+func (r *accountRepo) ListUsersPendingDeletion(requestedBefore time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&auth.User{}).Unscoped().
+		Where("deletion_requested_at IS NOT NULL AND deletion_requested_at <= ?", requestedBefore).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *accountRepo) ListImages(userID uint) ([]note.NoteImage, error) {
+	var images []note.NoteImage
+	err := r.db.Joins("JOIN notes ON notes.id = note_images.note_id").
+		Where("notes.user_id = ?", userID).
+		Find(&images).Error
+	return images, err
+}
+
+func (r *accountRepo) ListExports(userID uint) ([]DataExport, error) {
+	var exports []DataExport
+	err := r.db.Where("user_id = ?", userID).Find(&exports).Error
+	return exports, err
+}
+
+// PurgeUser hard deletes the user and every row that belongs to them.
+func (r *accountRepo) PurgeUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		noteIDs := tx.Model(&note.Note{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("note_id IN (?)", noteIDs).Delete(&note.NoteImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&note.Note{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&DataExport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM api_keys WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&auth.User{}, userID).Error
+	})
+}
+
+func (r *accountRepo) GetUser(userID uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *accountRepo) GetNotes(userID uint) ([]note.Note, error) {
+	var notes []note.Note
+	err := r.db.Preload("Images").Preload("Tags").Where("user_id = ?", userID).Order("created_at").Find(&notes).Error
+	return notes, err
+}
+
+func (r *accountRepo) CreateExport(export *DataExport) error {
+	return r.db.Create(export).Error
+}
+
+// UpdateExport saves everything but the heartbeat, which only TouchExport
+// moves forward.
+func (r *accountRepo) UpdateExport(export *DataExport) error {
+	return r.db.Omit("HeartbeatAt").Save(export).Error
+}
+
+func (r *accountRepo) GetExport(userID, exportID uint) (*DataExport, error) {
+	var export DataExport
+	if err := r.db.Where("id = ? AND user_id = ?", exportID, userID).First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *accountRepo) GetExportByID(exportID uint) (*DataExport, error) {
+	var export DataExport
+	if err := r.db.First(&export, exportID).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *accountRepo) GetActiveExport(userID uint) (*DataExport, error) {
+	var export DataExport
+	err := r.db.Where("user_id = ? AND status IN ?", userID, []string{ExportPending, ExportProcessing}).
+		Order("created_at desc").First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *accountRepo) ListExpiredExports(now time.Time) ([]DataExport, error) {
+	var exports []DataExport
+	err := r.db.Where("status = ? AND expires_at <= ?", ExportReady, now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *accountRepo) TouchExport(exportID uint, at time.Time) error {
+	return r.db.Model(&DataExport{}).Where("id = ?", exportID).Update("heartbeat_at", at).Error
+}
+
+// FailInterruptedExports marks exports that were being built when the
+// process building them stopped, so their owners can request a new one.
+func (r *accountRepo) FailInterruptedExports(worker string, staleBefore time.Time) error {
+	query := r.db.Model(&DataExport{}).Where("status IN ?", []string{ExportPending, ExportProcessing})
+	if worker != "" {
+		query = query.Where("worker = ?", worker)
+	} else {
+		query = query.Where("heartbeat_at IS NULL OR heartbeat_at < ?", staleBefore)
+	}
+	return query.Updates(map[string]interface{}{"status": ExportFailed, "error": "export was interrupted, please request a new one"}).Error
+}
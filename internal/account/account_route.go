@@ -0,0 +1,19 @@
+package account
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, accountHandler *AccountHandler, authMiddleware gin.HandlerFunc) {
+	me := router.Group("/api/v1/me")
+	me.Use(authMiddleware, token.RequireSession())
+	{
+		me.DELETE("", accountHandler.DeleteAccount)
+		me.POST("/export", accountHandler.RequestExport)
+		me.GET("/export/:id", accountHandler.GetExport)
+	}
+
+	router.GET("/api/v1/exports/:id/download", accountHandler.DownloadExport)
+}
@@ -0,0 +1,337 @@
+package account
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"notemind/internal/media"
+	"notemind/internal/scheduler"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultExportDir      = "exports"
+	archiveFolder         = "exports"
+	exportRetention       = 7 * 24 * time.Hour
+	downloadLinkTTL       = time.Hour
+	defaultWorkerInterval = time.Minute
+	exportHeartbeat       = 30 * time.Second
+	// an export whose heartbeat is older than this was left by a replica
+	// that stopped
+	exportStaleAfter = 5 * time.Minute
+)
+
+var (
+	ErrExportNotFound   = errors.New("export not found")
+	ErrExportNotReady   = errors.New("export is not ready")
+	ErrInvalidDownload  = errors.New("invalid or expired download link")
+	ErrDeletionPending  = errors.New("account is scheduled for deletion")
+	ErrExportInProgress = errors.New("an export is already in progress")
+	errNoDownloadSecret = errors.New("EXPORT_SIGNING_KEY or SECRET_KEY must be set")
+)
+
+type AccountService interface {
+	// RequestDeletion schedules the user and all of their data for removal.
+	RequestDeletion(userID uint) error
+	RequestExport(userID uint) (*DataExport, error)
+	GetExport(userID, exportID uint) (*DataExport, error)
+	// DownloadURL signs a link to a ready export that stays valid for an hour.
+	DownloadURL(export *DataExport) (string, time.Time, error)
+	// OpenDownload checks a signed link and returns the archive store URL
+	// the export is downloaded from.
+	OpenDownload(exportID uint, expires int64, signature string) (string, error)
+	// FailInterrupted marks the exports this replica was building when it
+	// last stopped.
+	FailInterrupted() error
+	// Maintain purges deleted accounts and expired exports and fails exports
+	// abandoned by replicas that stopped. It is meant to run periodically on
+	// one replica at a time.
+	Maintain(ctx context.Context) error
+}
+
+type accountService struct {
+	repo     AccountRepo
+	images   media.Store
+	archives media.ArchiveStore
+	instance string
+}
+
+func NewAccountService(repo AccountRepo, images media.Store, archives media.ArchiveStore) AccountService {
+	return &accountService{repo: repo, images: images, archives: archives, instance: scheduler.InstanceID()}
+}
+
+func (s *accountService) RequestDeletion(userID uint) error {
+	if userID == 0 {
+		return errors.New("user ID cannot be zero")
+	}
+	return s.repo.MarkDeletionRequested(userID, time.Now().UTC())
+}
+
+func (s *accountService) RequestExport(userID uint) (*DataExport, error) {
+	if userID == 0 {
+		return nil, errors.New("user ID cannot be zero")
+	}
+	user, err := s.repo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionRequestedAt != nil {
+		return nil, ErrDeletionPending
+	}
+	if active, err := s.repo.GetActiveExport(userID); err == nil {
+		return active, ErrExportInProgress
+	}
+
+	now := time.Now().UTC()
+	export := &DataExport{
+		UserID:      userID,
+		Status:      ExportPending,
+		Worker:      s.instance,
+		HeartbeatAt: &now,
+		CreatedAt:   now,
+	}
+	if err := s.repo.CreateExport(export); err != nil {
+		return nil, err
+	}
+
+	go s.buildExport(export)
+
+	return export, nil
+}
+
+func (s *accountService) GetExport(userID, exportID uint) (*DataExport, error) {
+	export, err := s.repo.GetExport(userID, exportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *accountService) DownloadURL(export *DataExport) (string, time.Time, error) {
+	if export.Status != ExportReady || export.ExpiresAt == nil {
+		return "", time.Time{}, ErrExportNotReady
+	}
+	expires := time.Now().Add(downloadLinkTTL)
+	if export.ExpiresAt.Before(expires) {
+		expires = *export.ExpiresAt
+	}
+	signature, err := signDownload(export.ID, expires.Unix())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	link := fmt.Sprintf("/api/v1/exports/%d/download?expires=%d&signature=%s", export.ID, expires.Unix(), signature)
+	return link, expires, nil
+}
+
+func (s *accountService) OpenDownload(exportID uint, expires int64, signature string) (string, error) {
+	expected, err := signDownload(exportID, expires)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(expected), []byte(signature)) || time.Now().Unix() > expires {
+		return "", ErrInvalidDownload
+	}
+	export, err := s.repo.GetExportByID(exportID)
+	if err != nil {
+		return "", ErrInvalidDownload
+	}
+	if export.Status != ExportReady || export.ArchiveID == "" {
+		return "", ErrInvalidDownload
+	}
+	return s.archives.URL(export.ArchiveID)
+}
+
+func signDownload(exportID uint, expires int64) (string, error) {
+	secret := os.Getenv("EXPORT_SIGNING_KEY")
+	if secret == "" {
+		secret = os.Getenv("SECRET_KEY")
+	}
+	if secret == "" {
+		return "", errNoDownloadSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatUint(uint64(exportID), 10) + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (s *accountService) buildExport(export *DataExport) {
+	stop := scheduler.Heartbeat(fmt.Sprintf("export %d", export.ID), exportHeartbeat, func(now time.Time) error {
+		return s.repo.TouchExport(export.ID, now)
+	})
+	defer stop()
+
+	export.Status = ExportProcessing
+	if err := s.repo.UpdateExport(export); err != nil {
+		log.Printf("export %d: failed to update status: %v", export.ID, err)
+	}
+
+	archiveID, err := s.storeArchive(export)
+	now := time.Now().UTC()
+	export.CompletedAt = &now
+	if err != nil {
+		log.Printf("export %d failed: %v", export.ID, err)
+		export.Status = ExportFailed
+		export.Error = "export failed, please try again later"
+	} else {
+		expiresAt := now.Add(exportRetention)
+		export.Status = ExportReady
+		export.ArchiveID = archiveID
+		export.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.UpdateExport(export); err != nil {
+		log.Printf("export %d: failed to save result: %v", export.ID, err)
+	}
+}
+
+// storeArchive writes the export's archive and uploads it to the archive
+// store, returning its ID there. The local copy is only kept meanwhile.
+func (s *accountService) storeArchive(export *DataExport) (string, error) {
+	path, err := s.writeArchive(export)
+	if err != nil {
+		return "", err
+	}
+	defer removeExportFile(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return s.archives.Upload(context.Background(), f, archiveFolder, filepath.Base(path))
+}
+
+// exportDir is where archives are written before they are uploaded.
+func exportDir() string {
+	if dir := os.Getenv("EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return defaultExportDir
+}
+
+func (s *accountService) FailInterrupted() error {
+	return s.repo.FailInterruptedExports(s.instance, time.Time{})
+}
+
+func (s *accountService) Maintain(ctx context.Context) error {
+	if err := s.repo.FailInterruptedExports("", time.Now().Add(-exportStaleAfter)); err != nil {
+		log.Printf("failed to reset abandoned exports: %v", err)
+	}
+	s.purgeDeletedAccounts(ctx)
+	s.expireExports(ctx)
+	return nil
+}
+
+// WorkerInterval is how often Maintain runs, ACCOUNT_WORKER_INTERVAL or a
+// minute.
+func WorkerInterval() time.Duration {
+	if v := os.Getenv("ACCOUNT_WORKER_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultWorkerInterval
+}
+
+func deletionGrace() time.Duration {
+	if v := os.Getenv("ACCOUNT_DELETION_GRACE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (s *accountService) purgeDeletedAccounts(ctx context.Context) {
+	userIDs, err := s.repo.ListUsersPendingDeletion(time.Now().Add(-deletionGrace()))
+	if err != nil {
+		log.Printf("failed to list accounts pending deletion: %v", err)
+		return
+	}
+	for _, userID := range userIDs {
+		if err := s.purgeUser(ctx, userID); err != nil {
+			// everything is left in place so the next run retries
+			log.Printf("failed to purge user %d: %v", userID, err)
+			continue
+		}
+		log.Printf("purged user %d", userID)
+	}
+}
+
+func (s *accountService) purgeUser(ctx context.Context, userID uint) error {
+	images, err := s.repo.ListImages(userID)
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		publicID := img.PublicID
+		if publicID == "" {
+			publicID = media.PublicIDFromURL(img.ImageURL)
+		}
+		if publicID == "" {
+			log.Printf("image %d has no recognisable public ID, skipping remote delete", img.ID)
+			continue
+		}
+		if err := s.images.Delete(ctx, publicID); err != nil {
+			return fmt.Errorf("delete image %d: %w", img.ID, err)
+		}
+	}
+
+	exports, err := s.repo.ListExports(userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if export.ArchiveID == "" {
+			continue
+		}
+		if err := s.archives.Delete(ctx, export.ArchiveID); err != nil {
+			return fmt.Errorf("delete export %d: %w", export.ID, err)
+		}
+	}
+
+	return s.repo.PurgeUser(userID)
+}
+
+func (s *accountService) expireExports(ctx context.Context) {
+	exports, err := s.repo.ListExpiredExports(time.Now())
+	if err != nil {
+		log.Printf("failed to list expired exports: %v", err)
+		return
+	}
+	for i := range exports {
+		if exports[i].ArchiveID != "" {
+			if err := s.archives.Delete(ctx, exports[i].ArchiveID); err != nil {
+				// still ready, so the next run retries
+				log.Printf("export %d: failed to delete archive: %v", exports[i].ID, err)
+				continue
+			}
+		}
+		exports[i].Status = ExportExpired
+		exports[i].ArchiveID = ""
+		if err := s.repo.UpdateExport(&exports[i]); err != nil {
+			log.Printf("export %d: failed to mark expired: %v", exports[i].ID, err)
+		}
+	}
+}
+
+func removeExportFile(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("failed to remove export file %s: %v", filepath.Base(path), err)
+	}
+}
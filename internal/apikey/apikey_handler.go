@@ -0,0 +1,94 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+func (h *APIKeyHandler) CreateKey(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, rawKey, err := h.apiKeyService.CreateKey(user.UserID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, ErrTooManyKeys) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Store this key now, it will not be shown again",
+		"key":     rawKey,
+		"api_key": toResponse(key),
+	})
+}
+
+func (h *APIKeyHandler) ListKeys(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+
+	res := make([]APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		res = append(res, toResponse(&keys[i]))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"api_keys": res})
+}
+
+func (h *APIKeyHandler) RevokeKey(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	keyID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || keyID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(user.UserID, uint(keyID)); err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
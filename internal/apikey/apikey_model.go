@@ -0,0 +1,35 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+)
+
+type APIKey struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	UserID  uint   `json:"user_id"`
+	Name    string `json:"name"`
+	Prefix  string `json:"prefix" gorm:"uniqueIndex"`
+	KeyHash string `json:"-"`
+	// Scopes is stored as a comma separated list, see ScopeList.
+	Scopes string `json:"-"`
+
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,66 @@
+package apikey
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepo interface {
+	Create(key *APIKey) error
+	GetByPrefix(prefix string) (*APIKey, error)
+	ListByUser(userID uint) ([]APIKey, error)
+	Revoke(userID, keyID uint, at time.Time) (bool, error)
+	TouchLastUsed(keyID uint, at time.Time) error
+	GetUserEmail(userID uint) (string, error)
+}
+
+type apiKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo(db *gorm.DB) APIKeyRepo {
+	return &apiKeyRepo{db: db}
+}
+
+func (r *apiKeyRepo) Create(key *APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepo) GetByPrefix(prefix string) (*APIKey, error) {
+	var key APIKey
+	if err := r.db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepo) ListByUser(userID uint) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+// Revoke reports false when the user has no such key that is still unrevoked.
+func (r *apiKeyRepo) Revoke(userID, keyID uint, at time.Time) (bool, error) {
+	res := r.db.Model(&APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *apiKeyRepo) TouchLastUsed(keyID uint, at time.Time) error {
+	return r.db.Model(&APIKey{}).Where("id = ?", keyID).Update("last_used_at", at).Error
+}
+
+func (r *apiKeyRepo) GetUserEmail(userID uint) (string, error) {
+	var email string
+	err := r.db.Table("users").Select("email").Where("id = ? AND deleted_at IS NULL AND deletion_requested_at IS NULL", userID).Scan(&email).Error
+	if err != nil {
+		return "", err
+	}
+	if email == "" {
+		return "", gorm.ErrRecordNotFound
+	}
+	return email, nil
+}
@@ -0,0 +1,17 @@
+package apikey
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, apiKeyHandler *APIKeyHandler, authMiddleware gin.HandlerFunc) {
+	keys := router.Group("/api/v1/api-keys")
+	keys.Use(authMiddleware, token.RequireSession())
+	{
+		keys.POST("", apiKeyHandler.CreateKey)
+		keys.GET("", apiKeyHandler.ListKeys)
+		keys.DELETE("/:id", apiKeyHandler.RevokeKey)
+	}
+}
@@ -0,0 +1,211 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"notemind/internal/token"
+)
+
+const maxKeysPerUser = 25
+
+// lastUsedResolution keeps authentication from writing to the database on
+// every request made with the same key.
+const lastUsedResolution = time.Minute
+
+var (
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrInvalidScope = errors.New("unknown scope")
+	ErrTooManyKeys  = errors.New("api key limit reached")
+)
+
+type APIKeyService interface {
+	// CreateKey returns the stored key and the raw secret, which is never
+	// retrievable again.
+	CreateKey(userID uint, name string, scopes []string, expiresAt *time.Time) (*APIKey, string, error)
+	ListKeys(userID uint) ([]APIKey, error)
+	RevokeKey(userID, keyID uint) error
+	Authenticate(rawKey string) (*token.Principal, error)
+}
+
+type apiKeyService struct {
+	repo APIKeyRepo
+}
+
+func NewAPIKeyService(repo APIKeyRepo) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func (s *apiKeyService) CreateKey(userID uint, name string, scopes []string, expiresAt *time.Time) (*APIKey, string, error) {
+	if userID == 0 {
+		return nil, "", errors.New("user ID cannot be zero")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("name is required")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", errors.New("expires_at must be in the future")
+	}
+	if len(scopes) == 0 {
+		scopes = token.Scopes
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	existing, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	active := 0
+	for i := range existing {
+		if existing[i].Active(time.Now()) {
+			active++
+		}
+	}
+	if active >= maxKeysPerUser {
+		return nil, "", ErrTooManyKeys
+	}
+
+	prefix, rawKey, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+	key := &APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashKey(rawKey),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.Create(key); err != nil {
+		return nil, "", err
+	}
+	return key, rawKey, nil
+}
+
+func (s *apiKeyService) ListKeys(userID uint) ([]APIKey, error) {
+	if userID == 0 {
+		return nil, errors.New("user ID cannot be zero")
+	}
+	return s.repo.ListByUser(userID)
+}
+
+func (s *apiKeyService) RevokeKey(userID, keyID uint) error {
+	revoked, err := s.repo.Revoke(userID, keyID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(rawKey string) (*token.Principal, error) {
+	prefix, ok := keyPrefix(rawKey)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	key, err := s.repo.GetByPrefix(prefix)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashKey(rawKey))) != 1 {
+		return nil, ErrInvalidKey
+	}
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return nil, errors.New("api key has been revoked")
+	}
+	if !key.Active(now) {
+		return nil, errors.New("api key has expired")
+	}
+
+	email, err := s.repo.GetUserEmail(key.UserID)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedResolution {
+		if err := s.repo.TouchLastUsed(key.ID, now.UTC()); err != nil {
+			log.Printf("failed to record api key usage: %v", err)
+		}
+	}
+
+	return &token.Principal{
+		UserID:   key.UserID,
+		Email:    email,
+		APIKeyID: key.ID,
+		Scopes:   key.ScopeList(),
+	}, nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, known := range token.Scopes {
+			if scope == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
+
+// generateKey returns a key shaped nmk_<8 hex>_<secret>. The public part up
+// to the second underscore is stored in clear so the key can be looked up and
+// recognised in listings.
+func generateKey() (prefix, rawKey string, err error) {
+	id := make([]byte, 4)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	prefix = token.APIKeyPrefix + hex.EncodeToString(id)
+	return prefix, prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func keyPrefix(rawKey string) (string, bool) {
+	if !strings.HasPrefix(rawKey, token.APIKeyPrefix) {
+		return "", false
+	}
+	rest := strings.TrimPrefix(rawKey, token.APIKeyPrefix)
+	parts := strings.SplitN(rest, "_", 2)
+	if len(parts) != 2 || len(parts[0]) != 8 || parts[1] == "" {
+		return "", false
+	}
+	return token.APIKeyPrefix + parts[0], true
+}
+
+func hashKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
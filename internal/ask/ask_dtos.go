@@ -0,0 +1,20 @@
+package ask
+
+type AskRequest struct {
+	Question string `json:"question" form:"question" binding:"required,max=500"`
+}
+
+// Citation points at the passage of a note an answer relies on.
+type Citation struct {
+	NoteID    uint   `json:"note_id"`
+	NoteTitle string `json:"note_title"`
+	Quote     string `json:"quote"`
+}
+
+// Answer is the reply to a question. When Answered is false the notes did
+// not support an answer and Text says so instead.
+type Answer struct {
+	Answered  bool       `json:"answered"`
+	Text      string     `json:"answer"`
+	Citations []Citation `json:"citations"`
+}
@@ -0,0 +1,95 @@
+package ask
+
+import (
+	"errors"
+	"net/http"
+
+	"notemind/internal/llm"
+	"notemind/internal/sse"
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AskHandler struct {
+	askService AskService
+}
+
+func NewAskHandler(askService AskService) *AskHandler {
+	return &AskHandler{askService: askService}
+}
+
+func (h *AskHandler) Ask(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req AskRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	answer, err := h.askService.Ask(ctx.Request.Context(), user.UserID, req.Question)
+	if err != nil {
+		if errors.Is(err, ErrEmptyQuestion) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, llm.ErrQuotaExceeded) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, llm.ErrBlocked) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": llm.ErrBlocked.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to answer the question"})
+		return
+	}
+	ctx.JSON(http.StatusOK, answer)
+}
+
+// AskStream answers like Ask but as Server-Sent Events: "token" events with
+// the answer as it is written, then "done" with the final Answer, which
+// replaces the streamed text when it is a refusal. GET takes the question
+// from the query string for EventSource clients, POST from a JSON body.
+func (h *AskHandler) AskStream(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req AskRequest
+	var err error
+	if ctx.Request.Method == http.MethodGet {
+		err = ctx.ShouldBindQuery(&req)
+	} else {
+		err = ctx.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sse.Begin(ctx)
+	answer, err := h.askService.AskStream(ctx.Request.Context(), user.UserID, req.Question, func(chunk string) error {
+		return sse.Send(ctx, "token", gin.H{"text": chunk})
+	})
+	if err != nil {
+		switch {
+		case ctx.Request.Context().Err() != nil:
+		case errors.Is(err, llm.ErrQuotaExceeded):
+			sse.Send(ctx, "error", gin.H{"error": err.Error()})
+		case errors.Is(err, llm.ErrBlocked):
+			sse.Send(ctx, "error", gin.H{"error": llm.ErrBlocked.Error()})
+		default:
+			sse.Send(ctx, "error", gin.H{"error": "Failed to answer the question"})
+		}
+		return
+	}
+	sse.Send(ctx, "done", answer)
+}
@@ -0,0 +1,114 @@
+package ask
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"notemind/internal/llm"
+)
+
+const (
+	maxTerms = 12
+	// maxCandidateChars bounds each note in the prompt.
+	maxCandidateChars = 3000
+)
+
+// searchTerms splits a question into words safe to use in a tsquery.
+func searchTerms(question string) []string {
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := map[string]bool{}
+	var terms []string
+	for _, w := range words {
+		if utf8.RuneCountInString(w) < 2 || seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+		if len(terms) == maxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+func buildPrompt(question string, candidates []Candidate) string {
+	var b strings.Builder
+	for _, c := range candidates {
+		fmt.Fprintf(&b, "[note %d] %s\n%s\n\n", c.ID, c.Title, truncate(c.Content, maxCandidateChars))
+	}
+	return fmt.Sprintf(`Answer the user's question using ONLY the notes below. They are the user's own notes.
+If the notes do not contain the answer, say so by setting "supported" to false. Do not use outside knowledge.
+Every claim must be backed by a citation: the note number and a short passage copied word for word from that note.
+
+Answer with JSON only, no markdown fences, in exactly this shape:
+{"supported": true, "answer": "the answer", "citations": [{"note_id": 1, "quote": "exact passage"}]}
+
+%s
+
+Notes:
+%s
+
+Question: %s`, llm.UntrustedNotice, llm.Untrusted("notes", b.String()), question)
+}
+
+type llmAnswer struct {
+	Supported bool          `json:"supported"`
+	Answer    string        `json:"answer"`
+	Citations []llmCitation `json:"citations"`
+}
+
+type llmCitation struct {
+	NoteID uint   `json:"note_id"`
+	Quote  string `json:"quote"`
+}
+
+func parseAnswer(raw string) (*llmAnswer, error) {
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("LLM answer is not JSON")
+	}
+	var ans llmAnswer
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &ans); err != nil {
+		return nil, fmt.Errorf("LLM answer is not valid JSON: %w", err)
+	}
+	return &ans, nil
+}
+
+// verifyCitations keeps only citations of candidate notes whose quote
+// really appears in the note, so the user never sees an invented source.
+func verifyCitations(ans *llmAnswer, candidates []Candidate) []Citation {
+	notes := make(map[uint]Candidate, len(candidates))
+	for _, c := range candidates {
+		notes[c.ID] = c
+	}
+	var citations []Citation
+	for _, c := range ans.Citations {
+		note, ok := notes[c.NoteID]
+		quote := strings.TrimSpace(c.Quote)
+		if !ok || quote == "" {
+			continue
+		}
+		if !strings.Contains(normalize(note.Content+" "+note.Title), normalize(quote)) {
+			continue
+		}
+		citations = append(citations, Citation{NoteID: note.ID, NoteTitle: note.Title, Quote: quote})
+	}
+	return citations
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "…"
+}
@@ -0,0 +1,46 @@
+package ask
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerifyCitations(t *testing.T) {
+	candidates := []Candidate{
+		{ID: 1, Title: "Team offsite", Content: "The offsite is in Porto.\nWe fly out on   Friday morning."},
+		{ID: 2, Title: "Budget", Content: "Travel budget is 500 euros per person."},
+	}
+	tests := []struct {
+		name  string
+		cited llmCitation
+		want  []Citation
+	}{
+		{
+			name:  "exact quote",
+			cited: llmCitation{NoteID: 1, Quote: "The offsite is in Porto."},
+			want:  []Citation{{NoteID: 1, NoteTitle: "Team offsite", Quote: "The offsite is in Porto."}},
+		},
+		{
+			name:  "case and spacing differ",
+			cited: llmCitation{NoteID: 1, Quote: " we fly out\non friday "},
+			want:  []Citation{{NoteID: 1, NoteTitle: "Team offsite", Quote: "we fly out\non friday"}},
+		},
+		{
+			name:  "quote of the title",
+			cited: llmCitation{NoteID: 2, Quote: "budget"},
+			want:  []Citation{{NoteID: 2, NoteTitle: "Budget", Quote: "budget"}},
+		},
+		{name: "quote not in the note", cited: llmCitation{NoteID: 1, Quote: "The offsite is in Lisbon."}},
+		{name: "quote of another note", cited: llmCitation{NoteID: 1, Quote: "500 euros per person"}},
+		{name: "note not searched", cited: llmCitation{NoteID: 3, Quote: "Porto"}},
+		{name: "empty quote", cited: llmCitation{NoteID: 1, Quote: "  "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := verifyCitations(&llmAnswer{Supported: true, Citations: []llmCitation{tt.cited}}, candidates)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("verifyCitations = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package ask
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Candidate is a note retrieved as possible support for an answer.
+type Candidate struct {
+	ID      uint
+	Title   string
+	Content string
+	Rank    float64
+}
+
+type AskRepo interface {
+	// Search returns the user's notes matching any of the terms, best first.
+	Search(userID uint, terms []string, limit int) ([]Candidate, error)
+}
+
+type askRepo struct {
+	db *gorm.DB
+}
+
+func NewAskRepo(db *gorm.DB) AskRepo {
+	return &askRepo{db: db}
+}
+
+// searchDocument must stay in sync with idx_notes_search for the index to be used.
+const searchDocument = "to_tsvector('english', title || ' ' || content_text)"
+
+func (r *askRepo) Search(userID uint, terms []string, limit int) ([]Candidate, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	// terms are plain words, OR-ing them lets a question match notes that
+	// only share some of its words while ts_rank favours those sharing more
+	query := strings.Join(terms, " | ")
+
+	var candidates []Candidate
+	err := r.db.Table("notes").
+		Select("id, title, content_text AS content, ts_rank("+searchDocument+", to_tsquery('english', ?)) AS rank", query).
+		Where("user_id = ? AND "+searchDocument+" @@ to_tsquery('english', ?)", userID, query).
+		Order("rank DESC, id DESC").
+		Limit(limit).
+		Scan(&candidates).Error
+	return candidates, err
+}
@@ -0,0 +1,14 @@
+package ask
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, askHandler *AskHandler, authMiddleware gin.HandlerFunc) {
+	v1 := router.Group("/api/v1")
+	v1.POST("/ask", authMiddleware, token.RequireScope(token.ScopeNotesRead), askHandler.Ask)
+	v1.GET("/ask/stream", authMiddleware, token.RequireScope(token.ScopeNotesRead), askHandler.AskStream)
+	v1.POST("/ask/stream", authMiddleware, token.RequireScope(token.ScopeNotesRead), askHandler.AskStream)
+}
@@ -0,0 +1,99 @@
+package ask
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"notemind/internal/llm"
+)
+
+const maxCandidates = 8
+
+// noSupportMessage is the refusal given when the notes do not answer the question.
+const noSupportMessage = "I couldn't find anything in your notes that answers this question."
+
+var ErrEmptyQuestion = errors.New("question is required")
+
+type AskService interface {
+	// Ask answers question from the user's own notes, or refuses when none
+	// of them support an answer.
+	Ask(ctx context.Context, userID uint, question string) (*Answer, error)
+	// AskStream is Ask passing the answer to onChunk while it is written.
+	// The returned Answer is authoritative: when it is a refusal, whatever
+	// was streamed must be discarded.
+	AskStream(ctx context.Context, userID uint, question string, onChunk func(string) error) (*Answer, error)
+}
+
+type askService struct {
+	repo AskRepo
+	llm  llm.Client
+}
+
+func NewAskService(repo AskRepo, llm llm.Client) AskService {
+	return &askService{repo: repo, llm: llm}
+}
+
+func (s *askService) Ask(ctx context.Context, userID uint, question string) (*Answer, error) {
+	question, candidates, err := s.retrieve(userID, question)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return refusal(), nil
+	}
+
+	raw, err := s.llm.Generate(llm.ForUser(ctx, userID, llm.FeatureAsk), buildPrompt(question, candidates))
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseAnswer(raw)
+	if err != nil {
+		return nil, err
+	}
+	return grounded(parsed, candidates), nil
+}
+
+func (s *askService) AskStream(ctx context.Context, userID uint, question string, onChunk func(string) error) (*Answer, error) {
+	question, candidates, err := s.retrieve(userID, question)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return refusal(), nil
+	}
+
+	stream := &answerStream{onChunk: onChunk}
+	ctx = llm.ForUser(ctx, userID, llm.FeatureAsk)
+	if _, err := s.llm.GenerateStream(ctx, buildStreamPrompt(question, candidates), stream.write); err != nil {
+		return nil, err
+	}
+	if err := stream.flush(); err != nil {
+		return nil, err
+	}
+	return grounded(stream.parse(), candidates), nil
+}
+
+// retrieve finds the notes that may answer question.
+func (s *askService) retrieve(userID uint, question string) (string, []Candidate, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return "", nil, ErrEmptyQuestion
+	}
+	candidates, err := s.repo.Search(userID, searchTerms(question), maxCandidates)
+	return question, candidates, err
+}
+
+// grounded turns the model's answer into a reply, refusing unless at least
+// one citation checks out.
+func grounded(parsed *llmAnswer, candidates []Candidate) *Answer {
+	citations := verifyCitations(parsed, candidates)
+	if !parsed.Supported || len(citations) == 0 || strings.TrimSpace(parsed.Answer) == "" {
+		return refusal()
+	}
+	return &Answer{Answered: true, Text: strings.TrimSpace(parsed.Answer), Citations: citations}
+}
+
+func refusal() *Answer {
+	return &Answer{Answered: false, Text: noSupportMessage, Citations: []Citation{}}
+}
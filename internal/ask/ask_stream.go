@@ -0,0 +1,109 @@
+package ask
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"notemind/internal/llm"
+)
+
+// The streamed answer is prose rather than JSON so it can be shown while it
+// is written. The model marks a refusal with notSupportedMarker and lists
+// its citations after sourcesMarker, which is never streamed.
+const (
+	notSupportedMarker = "NOT_SUPPORTED"
+	sourcesMarker      = "SOURCES:"
+)
+
+var sourceLine = regexp.MustCompile(`\[note (\d+)\][^"“]*["“](.+?)["”]`)
+
+func buildStreamPrompt(question string, candidates []Candidate) string {
+	var b strings.Builder
+	for _, c := range candidates {
+		fmt.Fprintf(&b, "[note %d] %s\n%s\n\n", c.ID, c.Title, truncate(c.Content, maxCandidateChars))
+	}
+	return fmt.Sprintf(`Answer the user's question using ONLY the notes below. They are the user's own notes.
+Do not use outside knowledge. If the notes do not contain the answer, reply with exactly %[1]s and nothing else.
+
+Otherwise write the answer in plain prose, then a line with exactly %[2]s followed by one line per passage you relied on,
+copied word for word from the note:
+[note 1] "exact passage"
+
+%[3]s
+
+Notes:
+%[4]s
+
+Question: %[5]s`, notSupportedMarker, sourcesMarker, llm.UntrustedNotice, llm.Untrusted("notes", b.String()), question)
+}
+
+// answerStream forwards the answer to onChunk as it is generated, holding
+// back anything that may still turn out to be a marker.
+type answerStream struct {
+	onChunk func(string) error
+	text    strings.Builder
+	sent    int
+	closed  bool
+}
+
+func (s *answerStream) write(chunk string) error {
+	s.text.WriteString(chunk)
+	if s.closed {
+		return nil
+	}
+	text := s.text.String()
+
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(notSupportedMarker, trimmed) || strings.HasPrefix(trimmed, notSupportedMarker) {
+		return nil
+	}
+
+	limit := len(text) - len(sourcesMarker) + 1
+	if i := strings.Index(text, sourcesMarker); i >= 0 {
+		limit = i
+		s.closed = true
+	}
+	for limit > s.sent && limit < len(text) && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	if limit <= s.sent {
+		return nil
+	}
+	out := text[s.sent:limit]
+	s.sent = limit
+	return s.onChunk(out)
+}
+
+// flush sends what was held back once the answer is complete.
+func (s *answerStream) flush() error {
+	text := s.text.String()
+	if s.closed || s.refused() || s.sent >= len(text) {
+		return nil
+	}
+	out := text[s.sent:]
+	s.sent = len(text)
+	return s.onChunk(out)
+}
+
+func (s *answerStream) refused() bool {
+	return strings.HasPrefix(strings.TrimSpace(s.text.String()), notSupportedMarker)
+}
+
+// parse splits the finished answer into prose and citations.
+func (s *answerStream) parse() *llmAnswer {
+	text := s.text.String()
+	ans := &llmAnswer{Supported: !s.refused()}
+	prose, sources, _ := strings.Cut(text, sourcesMarker)
+	ans.Answer = strings.TrimSpace(prose)
+	for _, m := range sourceLine.FindAllStringSubmatch(sources, -1) {
+		id, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		ans.Citations = append(ans.Citations, llmCitation{NoteID: uint(id), Quote: m[2]})
+	}
+	return ans
+}
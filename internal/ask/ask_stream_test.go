@@ -0,0 +1,94 @@
+package ask
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAnswerStreamWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunks    []string
+		sent      string
+		refused   bool
+		citations int
+	}{
+		{
+			name:   "answer",
+			chunks: []string{"The offsite ", "is in ", "Porto."},
+			sent:   "The offsite is in Porto.",
+		},
+		{
+			name:    "refusal",
+			chunks:  []string{"NOT_SUPPORTED"},
+			refused: true,
+		},
+		{
+			name:    "refusal split across chunks",
+			chunks:  []string{"\n NOT", "_SUPP", "ORTED\n"},
+			refused: true,
+		},
+		{
+			name:   "answer starting like the refusal",
+			chunks: []string{"NO", "TE: it is in Porto."},
+			sent:   "NOTE: it is in Porto.",
+		},
+		{
+			name:      "sources",
+			chunks:    []string{"In Porto.\n", "SOURCES:\n[note 1] \"The offsite is in Porto.\""},
+			sent:      "In Porto.\n",
+			citations: 1,
+		},
+		{
+			name:      "sources split across chunks",
+			chunks:    []string{"In Porto.\nSOU", "RC", "ES:\n[note 1] “in Porto”\n[note 2] \"Friday\""},
+			sent:      "In Porto.\n",
+			citations: 2,
+		},
+		{
+			name:   "multibyte rune split across chunks",
+			chunks: []string{"Caf\xc3", "\xa9 in Porto, ", "n\xc3\xa3", "o em Lisboa"},
+			sent:   "Café in Porto, não em Lisboa",
+		},
+		{
+			name:      "multibyte rune before the sources",
+			chunks:    []string{"Voilà \xe2\x9c", "\x93SOURCES:", "\n[note 1] \"voilà\""},
+			sent:      "Voilà ✓",
+			citations: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			s := &answerStream{onChunk: func(chunk string) error {
+				sent = append(sent, chunk)
+				return nil
+			}}
+			for _, chunk := range tt.chunks {
+				if err := s.write(chunk); err != nil {
+					t.Fatalf("write: %v", err)
+				}
+			}
+			if err := s.flush(); err != nil {
+				t.Fatalf("flush: %v", err)
+			}
+
+			if got := strings.Join(sent, ""); got != tt.sent {
+				t.Errorf("sent %q, want %q", got, tt.sent)
+			}
+			for _, chunk := range sent {
+				if !utf8.ValidString(chunk) {
+					t.Errorf("sent chunk %q splits a rune", chunk)
+				}
+			}
+			ans := s.parse()
+			if ans.Supported == tt.refused {
+				t.Errorf("supported = %v, want %v", ans.Supported, !tt.refused)
+			}
+			if len(ans.Citations) != tt.citations {
+				t.Errorf("citations = %+v, want %d", ans.Citations, tt.citations)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package auth
 
+import "time"
+
 
 type UserCreateRequest struct {
 
@@ -7,4 +9,57 @@ type UserCreateRequest struct {
 	 Email string `json:"email" binding:"required,email"`
 	 TimeZone string `json:"timezone" binding:"required"`
 
-}
\ No newline at end of file
+}
+
+// UpdateProfileRequest only changes the fields that are present.
+// An empty birthday clears it.
+type UpdateProfileRequest struct {
+	Name     *string `json:"name"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	TimeZone *string `json:"timezone"`
+	Birthday *string `json:"birthday"`
+	Gender   *string `json:"gender" binding:"omitempty,max=50"`
+
+	DigestPreferences *UpdateDigestPreferencesRequest `json:"digest_preferences"`
+}
+
+// UpdateDigestPreferencesRequest only changes the fields that are present.
+// Weekday counts from Sunday (0) and is only used by weekly digests.
+type UpdateDigestPreferencesRequest struct {
+	Frequency    *string `json:"frequency" binding:"omitempty,oneof=daily weekly off"`
+	SendHour     *int    `json:"send_hour" binding:"omitempty,min=0,max=23"`
+	Weekday      *int    `json:"weekday" binding:"omitempty,min=0,max=6"`
+	IncludeEmpty *bool   `json:"include_empty"`
+}
+
+type ProfileResponse struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	PendingEmail *string   `json:"pending_email"`
+	TimeZone     string    `json:"timezone"`
+	Birthday     *string   `json:"birthday"`
+	Gender       string    `json:"gender"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	DigestPreferences *DigestPreference `json:"digest_preferences"`
+}
+
+func toProfileResponse(user *User, prefs *DigestPreference) ProfileResponse {
+	res := ProfileResponse{
+		ID:           user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		PendingEmail: user.PendingEmail,
+		TimeZone:     user.Timezone,
+		Gender:       user.Gender,
+		CreatedAt:    user.CreatedAt,
+
+		DigestPreferences: prefs,
+	}
+	if user.Birthday != nil {
+		birthday := user.Birthday.Format(birthdayLayout)
+		res.Birthday = &birthday
+	}
+	return res
+}
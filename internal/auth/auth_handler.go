@@ -1,9 +1,13 @@
 package auth
 
 import (
+	"errors"
+	"html/template"
 	"net/http"
 	"log"
 
+	"notemind/internal/token"
+
 	"github.com/gin-gonic/gin"
 )
 type AuthHandler struct {
@@ -23,6 +27,12 @@ func(h *AuthHandler) CreateUser(ctx *gin.Context) {
 		 return 
 	 }
      token , err:=h.authService.LoginUser(req.Name,req.Email,req.TimeZone)
+	 if errors.Is(err, ErrAccountDeleted) {
+		 ctx.JSON(http.StatusForbidden, gin.H{
+			 "message":err.Error(),
+		 })
+		 return
+	 }
 	 if err != nil {
 		 ctx.JSON(http.StatusInternalServerError, gin.H{
 			 "message":"err",
@@ -38,51 +48,180 @@ func(h *AuthHandler) CreateUser(ctx *gin.Context) {
 
 
 
-func (h *AuthHandler) SendDailySummary(c *gin.Context) {
-	err := h.authService.SendDailySummary()
+// GetProfile returns the authenticated user's profile
+func (h *AuthHandler) GetProfile(c *gin.Context) {
+	user, ok := token.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	profile, err := h.authService.GetProfile(user.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	prefs, err := h.authService.GetDigestPreferences(user.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to send daily summary: " + err.Error(),
-		})
-		log.Println("server issue")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Daily summary sent successfully",
-	})
+	c.JSON(http.StatusOK, gin.H{"profile": toProfileResponse(profile, prefs)})
 }
 
-// GetProfile demonstrates how to access authenticated user information
-func (h *AuthHandler) GetProfile(c *gin.Context) {
-	// Get user information set by the middleware
-	userID, exists := c.Get("user_id")
-	if !exists {
+// UpdateProfile applies a partial update to the authenticated user's profile.
+// A new email address is only stored as pending until it is confirmed.
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	user, ok := token.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	userEmail, _ := c.Get("user_email")
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.authService.UpdateProfile(user.UserID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case errors.Is(err, ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case isInvalidField(err):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrConfirmationEmail):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			log.Printf("failed to update profile of user %d: %v", user.UserID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		}
+		return
+	}
+
+	prefs, err := h.authService.GetDigestPreferences(user.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
 
+	message := "Profile updated successfully"
+	if profile.PendingEmail != nil && req.Email != nil {
+		message = "Profile updated, check your new email address to confirm the change"
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"user_id":    userID,
-		"user_email": userEmail,
-		"message":    "Profile accessed successfully",
+		"message": message,
+		"profile": toProfileResponse(profile, prefs),
 	})
 }
 
-// UpdateProfile demonstrates how to use authenticated user for updates
-func (h *AuthHandler) UpdateProfile(c *gin.Context) {
-	// Get authenticated user ID
-	userID, exists := c.Get("user_id")
-	if !exists {
+// VerifyEmail confirms a pending email change from the link sent by email.
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	user, err := h.authService.ConfirmEmailChange(c.Query("token"))
+	if err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrInvalidEmailToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email address updated",
+		"email":   user.Email,
+	})
+}
+
+
+func (h *AuthHandler) GetDigestPreferences(c *gin.Context) {
+	user, ok := token.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	prefs, err := h.authService.GetDigestPreferences(user.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"digest_preferences": prefs})
+}
+
+func (h *AuthHandler) UpdateDigestPreferences(c *gin.Context) {
+	user, ok := token.CurrentUser(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
 		return
 	}
 
-	// Use the userID for database operations
+	var req UpdateDigestPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.authService.UpdateDigestPreferences(user.UserID, req)
+	if err != nil {
+		if isInvalidField(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("failed to update digest preferences of user %d: %v", user.UserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message":  "Profile updated successfully",
-		"user_id": userID.(uint), // Type assertion since we know it's uint
+		"message":            "Digest preferences updated",
+		"digest_preferences": prefs,
 	})
-}
\ No newline at end of file
+}
+
+// ConfirmUnsubscribeDigest is the page the unsubscribe link at the bottom of
+// digest emails opens. It changes nothing itself, its button posts the
+// token back to UnsubscribeDigest.
+func (h *AuthHandler) ConfirmUnsubscribeDigest(c *gin.Context) {
+	page := `<!DOCTYPE html><html><head><meta charset="utf-8"><title>NoteMind</title></head><body style="font-family: sans-serif; max-width: 600px; margin: 40px auto;"><h1>NoteMind</h1>` +
+		`<p>Do you want to stop receiving NoteMind summary emails?</p>` +
+		`<form method="post"><input type="hidden" name="token" value="` + template.HTMLEscapeString(c.Query("token")) + `"><button type="submit">Unsubscribe</button></form></body></html>`
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+}
+
+// UnsubscribeDigest turns the digest off, for the confirmation page and for
+// mail clients' one-click unsubscribe. It needs no login, the signed token
+// identifies the user.
+func (h *AuthHandler) UnsubscribeDigest(c *gin.Context) {
+	rawToken := c.PostForm("token")
+	if rawToken == "" {
+		// one-click requests carry the token in the URL of the email's header
+		rawToken = c.Query("token")
+	}
+	err := h.authService.UnsubscribeDigest(rawToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidUnsubscribeToken) || errors.Is(err, ErrUserNotFound) {
+			c.Data(http.StatusBadRequest, "text/html; charset=utf-8", []byte(unsubscribePage("This unsubscribe link is not valid.")))
+			return
+		}
+		log.Printf("unsubscribe failed: %v", err)
+		c.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(unsubscribePage("Something went wrong, please try again later.")))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(unsubscribePage("You will no longer receive NoteMind summary emails. You can turn them back on from your settings.")))
+}
+
+func unsubscribePage(message string) string {
+	return `<!DOCTYPE html><html><head><meta charset="utf-8"><title>NoteMind</title></head><body style="font-family: sans-serif; max-width: 600px; margin: 40px auto;"><h1>NoteMind</h1><p>` + message + `</p></body></html>`
+}
@@ -5,6 +5,12 @@ import (
 
 	"gorm.io/gorm"
 	"notemind/internal/note"
+	"notemind/internal/token"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = token.RoleAdmin
 )
 
 type User struct {
@@ -12,13 +18,51 @@ type User struct {
 	Name     string `json:"name" gorm:"not null"`
 	Email    string `json:"email" gorm:"uniqueIndex;not null"`
 
-	Birthday time.Time `json:"birthday"`
-	Gender   string    `json:"gender"`
-	Timezone string    `json:"timezone"`
+	Birthday *time.Time `json:"birthday" gorm:"type:date"`
+	Gender   string     `json:"gender"`
+	Timezone string     `json:"timezone"`
+	Role     string     `json:"role" gorm:"default:user"`
+
+	// An email change only takes effect once the new address is confirmed.
+	PendingEmail         *string    `json:"pending_email"`
+	EmailChangeTokenHash *string    `json:"-"`
+	EmailChangeExpiresAt *time.Time `json:"-"`
 
 	Notes []note.Note `json:"notes" gorm:"foreignKey:UserID"`
 
+	// Set when the user asked for their account to be deleted; the account
+	// package purges such users in the background.
+	DeletionRequestedAt *time.Time `json:"-"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
+
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+	DigestOff    = "off"
+)
+
+// DigestPreference controls when a user gets the recap email. Users without
+// a row get DefaultDigestPreference.
+type DigestPreference struct {
+	UserID       uint      `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Frequency    string    `json:"frequency"`
+	SendHour     int       `json:"send_hour"`
+	Weekday      int       `json:"weekday"`
+	IncludeEmpty bool      `json:"include_empty"`
+	CreatedAt    time.Time `json:"-"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func DefaultDigestPreference(userID uint) *DigestPreference {
+	return &DigestPreference{
+		UserID:       userID,
+		Frequency:    DigestDaily,
+		SendHour:     20,
+		Weekday:      int(time.Sunday),
+		IncludeEmpty: true,
+	}
+}
@@ -1,21 +1,29 @@
 package auth
 
-import "github.com/gin-gonic/gin"
+import (
+	"notemind/internal/token"
 
-func SetUpRoutes(router *gin.Engine, authHandler *AuthHandler) {
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, authHandler *AuthHandler, authMiddleware gin.HandlerFunc) {
 	v1 := router.Group("/api/v1")
 	{
 
 		v1.POST("/auth/user",authHandler.CreateUser)
-		// Protected route - requires authentication
-		v1.POST("/auth/send-daily-summary", authHandler.SendDailySummary)
+		v1.GET("/auth/verify-email", authHandler.VerifyEmail)
+		// one-click unsubscribe: only the POST mail clients send (RFC 8058)
+		// acts, the GET a link scanner may fetch just asks for confirmation
+		v1.GET("/digest/unsubscribe", authHandler.ConfirmUnsubscribeDigest)
+		v1.POST("/digest/unsubscribe", authHandler.UnsubscribeDigest)
 	}
 
-	// Protected routes that require authentication
-	protected := router.Group("/api/v1/protected")
-	protected.Use(AuthMiddleware())
+	me := router.Group("/api/v1/me")
+	me.Use(authMiddleware)
 	{
-		protected.GET("/profile", authHandler.GetProfile)
-		protected.PUT("/profile", authHandler.UpdateProfile)
+		me.GET("", authHandler.GetProfile)
+		me.PATCH("", token.RequireSession(), authHandler.UpdateProfile)
+		me.GET("/digest-preferences", authHandler.GetDigestPreferences)
+		me.PATCH("/digest-preferences", token.RequireSession(), authHandler.UpdateDigestPreferences)
 	}
 }
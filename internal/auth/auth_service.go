@@ -1,35 +1,51 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
 	"time"
-	"os"
 	"log"
 
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"notemind/internal/mailer"
+	"notemind/internal/token"
 )
 
 
 type AuthService interface {
      LoginUser(name, email , timezone string) (string , error ) 
-	 GenerateToken(userID uint, email string) (string , error)
+	 GenerateToken(userID uint, email, role string) (string , error)
+	 // AccountActive reports whether the user still exists and has not asked
+	 // for their account to be deleted.
+	 AccountActive(userID uint) (bool, error)
+
 
-	 SendDailySummary() error 
+	 GetProfile(userID uint) (*User, error)
+	 GetDigestPreferences(userID uint) (*DigestPreference, error)
+	 UpdateDigestPreferences(userID uint, req UpdateDigestPreferencesRequest) (*DigestPreference, error)
+	 // UnsubscribeDigest turns the digest off for the user a signed link belongs to.
+	 UnsubscribeDigest(token string) error
+	 UpdateProfile(userID uint, req UpdateProfileRequest) (*User, error)
+	 ConfirmEmailChange(rawToken string) (*User, error)
 }
 
 type authService struct {
 	 repo AuthRepo 
+	 tokens *token.Manager
+	 mail mailer.Mailer
 }
 
-type JWTClaims struct {
-	 UserID uint `json:"user_id"`
-	 Email string `json:"email"`
-	 jwt.RegisteredClaims
-}
 
-
-func NewAuthService (repo AuthRepo) AuthService {
-	 return &authService{repo: repo}
+func NewAuthService (repo AuthRepo, tokens *token.Manager, mail mailer.Mailer) AuthService {
+	 return &authService{repo: repo, tokens: tokens, mail: mail}
 }
 
 func(s *authService) LoginUser(name, email , timezone string) (string , error) {
@@ -42,6 +58,9 @@ func(s *authService) LoginUser(name, email , timezone string) (string , error) {
 	 if timezone=="" {
 		 return "",errors.New("timezone is required")
 	 }
+	 if err := validateTimezone(timezone); err != nil {
+		 return "", err
+	 }
 
 	 _, err := s.repo.GetByEmail(email)
 	 if err != nil {  
@@ -62,7 +81,10 @@ func(s *authService) LoginUser(name, email , timezone string) (string , error) {
 		log.Println("user does not exists")
 		 return "", err 
 	 }
-	 res , err :=s.GenerateToken(user.ID, user.Email)
+	 if user.DeletionRequestedAt != nil {
+		 return "", ErrAccountDeleted
+	 }
+	 res , err :=s.GenerateToken(user.ID, user.Email, user.Role)
 	 if err != nil {
 		log.Println("token creation issue")
 		 return "", err 
@@ -71,32 +93,290 @@ func(s *authService) LoginUser(name, email , timezone string) (string , error) {
 
 }
 
-func(s *authService) GenerateToken(userID uint , email string) (string , error) {
-	 secretKey:= os.Getenv("SECRET_KEY")
-	 if secretKey == "" {
-		 return "",errors.New("secret key is required")
-	 }
-	 claims:= &JWTClaims{
-		UserID: userID,
-		Email: email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24*time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+func(s *authService) GenerateToken(userID uint , email, role string) (string , error) {
+	 return s.tokens.Sign(userID, email, role)
+}
+
+func (s *authService) AccountActive(userID uint) (bool, error) {
+	 user, err := s.repo.GetByID(userID)
+	 if errors.Is(err, gorm.ErrRecordNotFound) {
+		 return false, nil
 	 }
-	 token:= jwt.NewWithClaims(jwt.SigningMethodHS256,claims)
-	 tokenString , err := token.SignedString([]byte(secretKey))
 	 if err != nil {
+		 return false, err
+	 }
+	 return user.DeletionRequestedAt == nil, nil
+}
+
+
+const (
+	birthdayLayout      = "2006-01-02"
+	emailChangeTokenTTL = 24 * time.Hour
+	defaultAppBaseURL   = "http://localhost:8080"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrEmailTaken        = errors.New("email is already in use")
+	ErrInvalidEmailToken = errors.New("invalid or expired email confirmation token")
+	ErrAccountDeleted    = errors.New("account is scheduled for deletion")
+	ErrConfirmationEmail = errors.New("failed to send confirmation email")
+)
+
+// Profile and digest preference fields that were rejected.
+var (
+	ErrEmptyName         = errors.New("name cannot be empty")
+	ErrInvalidTimezone   = errors.New("invalid timezone")
+	ErrInvalidBirthday   = errors.New("birthday must be formatted as YYYY-MM-DD")
+	ErrFutureBirthday    = errors.New("birthday cannot be in the future")
+	ErrInvalidFrequency  = errors.New("frequency must be daily, weekly or off")
+	ErrInvalidDigestHour = errors.New("send_hour must be between 0 and 23")
+	ErrInvalidWeekday    = errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+)
+
+// invalidFields are the errors a profile or digest preference update is
+// refused with because of what was asked, rather than the server failing.
+var invalidFields = []error{
+	ErrEmptyName, ErrInvalidTimezone, ErrInvalidBirthday, ErrFutureBirthday,
+	ErrInvalidFrequency, ErrInvalidDigestHour, ErrInvalidWeekday,
+}
+
+// isInvalidField reports whether err rejects a field of the request.
+func isInvalidField(err error) bool {
+	for _, invalid := range invalidFields {
+		if errors.Is(err, invalid) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateTimezone(timezone string) error {
+	// LoadLocation accepts "" and "Local" as the server zone, which is never
+	// what a user means.
+	if timezone == "" || timezone == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return fmt.Errorf("%w %q", ErrInvalidTimezone, timezone)
+	}
+	return nil
+}
+
+func (s *authService) GetProfile(userID uint) (*User, error) {
+	if userID == 0 {
+		return nil, errors.New("user ID cannot be zero")
+	}
+	user, err := s.repo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *authService) UpdateProfile(userID uint, req UpdateProfileRequest) (*User, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, ErrEmptyName
+		}
+		user.Name = name
+	}
+	if req.TimeZone != nil {
+		if err := validateTimezone(*req.TimeZone); err != nil {
+			return nil, err
+		}
+		user.Timezone = *req.TimeZone
+	}
+	if req.Birthday != nil {
+		if *req.Birthday == "" {
+			user.Birthday = nil
+		} else {
+			birthday, err := time.Parse(birthdayLayout, *req.Birthday)
+			if err != nil {
+				return nil, ErrInvalidBirthday
+			}
+			if birthday.After(time.Now()) {
+				return nil, ErrFutureBirthday
+			}
+			user.Birthday = &birthday
+		}
+	}
+	if req.Gender != nil {
+		user.Gender = strings.TrimSpace(*req.Gender)
+	}
+
+	var rawToken string
+	if req.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*req.Email))
+		if email != strings.ToLower(user.Email) {
+			if existing, err := s.repo.GetByEmail(email); err == nil && existing.ID != user.ID {
+				return nil, ErrEmailTaken
+			}
+			rawToken, err = newEmailChangeToken()
+			if err != nil {
+				return nil, err
+			}
+			tokenHash := hashEmailChangeToken(rawToken)
+			expiresAt := time.Now().Add(emailChangeTokenTTL).UTC()
+			user.PendingEmail = &email
+			user.EmailChangeTokenHash = &tokenHash
+			user.EmailChangeExpiresAt = &expiresAt
+		}
+	}
+
+	var prefs *DigestPreference
+	if req.DigestPreferences != nil {
+		if prefs, err = s.GetDigestPreferences(userID); err != nil {
+			return nil, err
+		}
+		if err := applyDigestPreferences(prefs, *req.DigestPreferences); err != nil {
+			return nil, err
+		}
+	}
+
+	// nothing is saved until everything is valid and the confirmation went
+	// out, a pending email nobody can confirm would only get in the way
+	if rawToken != "" {
+		if err := s.sendEmailChangeConfirmation(user, rawToken); err != nil {
+			log.Printf("failed to send email confirmation to user %d: %v", user.ID, err)
+			return nil, ErrConfirmationEmail
+		}
+	}
+	user.UpdatedAt = time.Now().UTC()
+	if prefs != nil {
+		prefs.UpdatedAt = user.UpdatedAt
+	}
+	if err := s.repo.SaveProfile(user, prefs); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+	return user, nil
+}
+
+func (s *authService) ConfirmEmailChange(rawToken string) (*User, error) {
+	if rawToken == "" {
+		return nil, ErrInvalidEmailToken
+	}
+	user, err := s.repo.GetByEmailChangeToken(hashEmailChangeToken(rawToken))
+	if err != nil {
+		return nil, ErrInvalidEmailToken
+	}
+	if user.PendingEmail == nil || user.EmailChangeExpiresAt == nil || time.Now().After(*user.EmailChangeExpiresAt) {
+		return nil, ErrInvalidEmailToken
+	}
+	// the address may have been claimed by someone else since the request
+	if existing, err := s.repo.GetByEmail(*user.PendingEmail); err == nil && existing.ID != user.ID {
+		return nil, ErrEmailTaken
+	}
+
+	user.Email = *user.PendingEmail
+	user.PendingEmail = nil
+	user.EmailChangeTokenHash = nil
+	user.EmailChangeExpiresAt = nil
+	user.UpdatedAt = time.Now().UTC()
+	if err := s.repo.Update(user); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+	return user, nil
+}
+
+func (s *authService) sendEmailChangeConfirmation(user *User, rawToken string) error {
+	link := appBaseURL() + "/api/v1/auth/verify-email?token=" + url.QueryEscape(rawToken)
+
+	subject := "Confirm your new NoteMind email address"
+	text := fmt.Sprintf("Hello %s,\n\nConfirm that you want to use this address for NoteMind by opening the link below within 24 hours:\n\n%s\n\nIf you did not ask for this change you can ignore this email.", user.Name, link)
+	html := fmt.Sprintf(`<p>Hello %s,</p><p>Confirm that you want to use this address for NoteMind by opening the link below within 24 hours:</p><p><a href="%s">Confirm email address</a></p><p>If you did not ask for this change you can ignore this email.</p>`, template.HTMLEscapeString(user.Name), template.HTMLEscapeString(link))
+
+	return s.mail.Send(context.Background(), mailer.Message{
+		To:      mailer.Address{Email: *user.PendingEmail, Name: user.Name},
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	})
+}
+
+func newEmailChangeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
+	return hex.EncodeToString(b), nil
+}
 
-	return tokenString, nil
+func hashEmailChangeToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
 }
 
+func (s *authService) GetDigestPreferences(userID uint) (*DigestPreference, error) {
+	if userID == 0 {
+		return nil, errors.New("user ID cannot be zero")
+	}
+	return s.repo.GetDigestPreference(userID)
+}
 
-func(s *authService) SendDailySummary() error {
-	 if err := s.repo.SendDailySummary(); err != nil {
-		 return err 
-	 }
-	 return nil 
-}
\ No newline at end of file
+func (s *authService) UpdateDigestPreferences(userID uint, req UpdateDigestPreferencesRequest) (*DigestPreference, error) {
+	prefs, err := s.GetDigestPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyDigestPreferences(prefs, req); err != nil {
+		return nil, err
+	}
+
+	prefs.UpdatedAt = time.Now().UTC()
+	if err := s.repo.SaveDigestPreference(prefs); err != nil {
+		return nil, fmt.Errorf("failed to update digest preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// applyDigestPreferences validates the fields present in req and sets them
+// on prefs.
+func applyDigestPreferences(prefs *DigestPreference, req UpdateDigestPreferencesRequest) error {
+	if req.Frequency != nil {
+		switch *req.Frequency {
+		case DigestDaily, DigestWeekly, DigestOff:
+			prefs.Frequency = *req.Frequency
+		default:
+			return ErrInvalidFrequency
+		}
+	}
+	if req.SendHour != nil {
+		if *req.SendHour < 0 || *req.SendHour > 23 {
+			return ErrInvalidDigestHour
+		}
+		prefs.SendHour = *req.SendHour
+	}
+	if req.Weekday != nil {
+		if *req.Weekday < 0 || *req.Weekday > 6 {
+			return ErrInvalidWeekday
+		}
+		prefs.Weekday = *req.Weekday
+	}
+	if req.IncludeEmpty != nil {
+		prefs.IncludeEmpty = *req.IncludeEmpty
+	}
+	return nil
+}
+
+func (s *authService) UnsubscribeDigest(token string) error {
+	userID, err := parseUnsubscribeToken(token)
+	if err != nil {
+		return err
+	}
+	if _, err := s.GetProfile(userID); err != nil {
+		return err
+	}
+	off := DigestOff
+	_, err = s.UpdateDigestPreferences(userID, UpdateDigestPreferencesRequest{Frequency: &off})
+	return err
+}
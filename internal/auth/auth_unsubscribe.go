@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe link")
+
+func unsubscribeSecret() ([]byte, error) {
+	secret := os.Getenv("DIGEST_UNSUBSCRIBE_SECRET")
+	if secret == "" {
+		secret = os.Getenv("SECRET_KEY")
+	}
+	if secret == "" {
+		return nil, errors.New("DIGEST_UNSUBSCRIBE_SECRET or SECRET_KEY must be set")
+	}
+	return []byte(secret), nil
+}
+
+// unsubscribeToken is "<user id>.<signature>". It never expires so links in
+// old emails keep working.
+func unsubscribeToken(userID uint) (string, error) {
+	secret, err := unsubscribeSecret()
+	if err != nil {
+		return "", err
+	}
+	id := strconv.FormatUint(uint64(userID), 10)
+	return id + "." + signUnsubscribe(secret, id), nil
+}
+
+func parseUnsubscribeToken(token string) (uint, error) {
+	secret, err := unsubscribeSecret()
+	if err != nil {
+		return 0, err
+	}
+	id, signature, found := strings.Cut(token, ".")
+	if !found {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	if !hmac.Equal([]byte(signature), []byte(signUnsubscribe(secret, id))) {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	userID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil || userID == 0 {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	return uint(userID), nil
+}
+
+func signUnsubscribe(secret []byte, id string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("digest-unsubscribe:" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// UnsubscribeURL is the one-click link that turns the user's digest off.
+func UnsubscribeURL(userID uint) (string, error) {
+	token, err := unsubscribeToken(userID)
+	if err != nil {
+		return "", err
+	}
+	return appBaseURL() + "/api/v1/digest/unsubscribe?token=" + url.QueryEscape(token), nil
+}
+
+func appBaseURL() string {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultAppBaseURL
+	}
+	return strings.TrimRight(baseURL, "/")
+}
@@ -0,0 +1,49 @@
+package digest
+
+import (
+	"time"
+
+	"notemind/internal/auth"
+)
+
+const (
+	DigestSent    = "sent"
+	DigestFailed  = "failed"
+	DigestSkipped = "skipped"
+)
+
+// DigestRunReport describes what a digest run did for every user it considered.
+type DigestRunReport struct {
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt time.Time          `json:"finished_at"`
+	Total      int                `json:"total"`
+	Sent       int                `json:"sent"`
+	Failed     int                `json:"failed"`
+	Skipped    int                `json:"skipped"`
+	Results    []DigestUserResult `json:"results"`
+}
+
+type DigestUserResult struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (r *DigestRunReport) add(user auth.User, status, reason string) {
+	r.Results = append(r.Results, DigestUserResult{
+		UserID: user.ID,
+		Email:  user.Email,
+		Status: status,
+		Reason: reason,
+	})
+	r.Total++
+	switch status {
+	case DigestSent:
+		r.Sent++
+	case DigestFailed:
+		r.Failed++
+	case DigestSkipped:
+		r.Skipped++
+	}
+}
@@ -0,0 +1,72 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DigestHandler struct {
+	digestService DigestService
+}
+
+func NewDigestHandler(digestService DigestService) *DigestHandler {
+	return &DigestHandler{digestService: digestService}
+}
+
+// SendDailySummary runs the digest and reports the outcome for every user.
+func (h *DigestHandler) SendDailySummary(c *gin.Context) {
+	// a cron caller hanging up must not abort a run halfway through
+	report, err := h.digestService.Run(context.WithoutCancel(c.Request.Context()))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to send daily summary: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Daily summary run finished",
+		"report":  report,
+	})
+}
+
+// PreviewDigest shows an admin the digest a user would receive right now.
+// format selects html (default), text or json.
+func (h *DigestHandler) PreviewDigest(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id must be a positive integer"})
+		return
+	}
+
+	msg, err := h.digestService.Preview(c.Request.Context(), uint(userID))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render digest: " + err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "html") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(msg.HTML))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(msg.Text))
+	case "json":
+		c.JSON(http.StatusOK, gin.H{
+			"subject": msg.Subject,
+			"to":      msg.To,
+			"headers": msg.Headers,
+			"html":    msg.HTML,
+			"text":    msg.Text,
+		})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html, text or json"})
+	}
+}
@@ -0,0 +1,42 @@
+package digest
+
+import "time"
+
+// DigestRun records the digest of one user for one day in their timezone.
+// The unique (user_id, local_date) pair is what keeps replicas and restarts
+// from mailing the same user twice.
+type DigestRun struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id"`
+	LocalDate time.Time `json:"local_date" gorm:"type:date"`
+	Status    string    `json:"status"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Content is everything a digest is generated from.
+type Content struct {
+	Frequency string
+	Summaries []string
+	// DueQuestions are a sample of the review cards due today, DueCount is
+	// how many are due in total.
+	DueQuestions []string
+	DueCount     int64
+	// OverdueTasks are a sample of the open tasks due before today,
+	// OverdueCount is how many there are in total.
+	OverdueTasks []OverdueTask
+	OverdueCount int64
+}
+
+// OverdueTask is an open action item past its due date.
+type OverdueTask struct {
+	Text    string
+	DueDate time.Time
+}
+
+// Empty reports whether there is nothing to write about.
+func (c Content) Empty() bool {
+	return len(c.Summaries) == 0 && c.DueCount == 0 && c.OverdueCount == 0
+}
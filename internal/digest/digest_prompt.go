@@ -0,0 +1,87 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+
+	"notemind/internal/auth"
+	"notemind/internal/llm"
+)
+
+// quietMessage is sent instead of an LLM digest when there are no notes.
+func quietMessage(frequency string) string {
+	review, period, next := "Daily", "Today", "Tomorrow"
+	if frequency == auth.DigestWeekly {
+		review, period, next = "Weekly", "This week", "Next week"
+	}
+	return fmt.Sprintf(`## 📝 Your %s Reflection
+
+%s was quiet on the note-taking front, but that's perfectly okay!
+
+🌟 %s is a fresh opportunity to capture your thoughts, ideas, and discoveries.
+
+Keep growing, keep learning! ✨`, review, period, next)
+}
+
+// fallbackMessage is sent when the LLM fails, so the user still hears from us.
+func fallbackMessage(frequency string) string {
+	period := "today"
+	if frequency == auth.DigestWeekly {
+		period = "this week"
+	}
+	return fmt.Sprintf("We couldn't generate your summary %s, but keep up the great work! 🌟", period)
+}
+
+// BuildPrompt asks for a markdown recall-practice review of the content.
+func BuildPrompt(content Content) string {
+	period, review := "today", "daily"
+	if content.Frequency == auth.DigestWeekly {
+		period, review = "this week", "weekly"
+	}
+
+	return fmt.Sprintf(`
+You are a personal learning assistant. The user wrote several notes %[1]s, but they might forget them if not reinforced. Your job is to help them RECALL and PRACTICE what they wrote by creating an engaging %[2]s review.
+
+Create a summary that:
+
+🧠 **RECALL CHALLENGE** (Test their memory):
+Start with 2-3 questions to test if they remember key points from their notes:
+- "Do you remember what you wrote about...?"
+- "Can you recall the main insight you had about...?"
+- "What was the key point you discovered regarding...?"
+
+📝 **YOUR NOTES SUMMARY** (Reinforce their learning):
+- Summarize their notes in an engaging, easy-to-remember way
+- Highlight the most important insights they wrote
+- Connect different notes to show patterns in their thinking
+- Use their own words and concepts when possible
+
+💡 **KEY TAKEAWAYS TO REMEMBER**:
+- Extract 2-3 main lessons from their notes
+- Present them as memorable, practical insights
+- Help them see the value in what they wrote
+🎯 **PRACTICE REMINDER**:
+End with encouragement to keep writing and a simple reminder of why their notes matter.
+
+Make it personal, engaging, and focused on helping them remember and value what they wrote %[1]s.
+Format the answer as markdown.
+%[5]s
+
+User's note summaries from %[1]s:
+%[3]s
+%[4]s
+Create their recall-practice summary:`, period, review, llm.Untrusted("summaries", strings.Join(content.Summaries, "\n\n")), dueSection(content), llm.UntrustedNotice)
+}
+
+// dueSection lets the recall challenge reuse the flashcards that are due.
+func dueSection(content Content) string {
+	if len(content.DueQuestions) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, q := range content.DueQuestions {
+		b.WriteString("- " + q + "\n")
+	}
+	return "\nFlashcard questions from older notes that are due for review today. Use some of them in the RECALL CHALLENGE, without giving the answers:\n" +
+		llm.Untrusted("flashcards", b.String()) + "\n"
+}
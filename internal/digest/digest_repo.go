@@ -0,0 +1,140 @@
+package digest
+
+import (
+	"time"
+
+	"notemind/internal/auth"
+
+	"gorm.io/gorm"
+)
+
+type DigestRepo interface {
+	// ListRecipients returns users with a timezone that are not being deleted.
+	ListRecipients() ([]auth.User, error)
+	GetUser(userID uint) (*auth.User, error)
+	// GetPreferences returns the preferences of every user, falling back to
+	// the defaults for users who never saved any.
+	GetPreferences(userIDs []uint) (map[uint]*auth.DigestPreference, error)
+	// ClaimRun reserves the user's digest for localDate. Only one caller
+	// across all replicas gets true; a failed run can be claimed again until
+	// it runs out of attempts.
+	ClaimRun(userID uint, localDate time.Time) (bool, error)
+	FinishRun(userID uint, localDate time.Time, status string, runErr error) error
+	// NoteSummaries returns the summaries of notes created in [from, to).
+	NoteSummaries(userID uint, from, to time.Time) ([]string, error)
+	// DueQuestions returns up to limit questions of review cards due before
+	// the given time, and how many are due in total.
+	DueQuestions(userID uint, before time.Time, limit int) ([]string, int64, error)
+	// OverdueTasks returns up to limit open tasks due before the given
+	// date, and how many there are in total.
+	OverdueTasks(userID uint, before time.Time, limit int) ([]OverdueTask, int64, error)
+}
+
+// maxDigestAttempts bounds how often a failed digest is retried on the same day.
+const maxDigestAttempts = 3
+
+const localDateLayout = "2006-01-02"
+
+type digestRepo struct {
+	db *gorm.DB
+}
+
+func NewDigestRepo(db *gorm.DB) DigestRepo {
+	return &digestRepo{db: db}
+}
+
+func (r *digestRepo) ListRecipients() ([]auth.User, error) {
+	var users []auth.User
+	err := r.db.Where("timezone IS NOT NULL AND timezone != '' AND deletion_requested_at IS NULL").Find(&users).Error
+	return users, err
+}
+
+func (r *digestRepo) GetUser(userID uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *digestRepo) GetPreferences(userIDs []uint) (map[uint]*auth.DigestPreference, error) {
+	prefs := make(map[uint]*auth.DigestPreference, len(userIDs))
+	if len(userIDs) == 0 {
+		return prefs, nil
+	}
+	var rows []auth.DigestPreference
+	if err := r.db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		prefs[rows[i].UserID] = &rows[i]
+	}
+	for _, id := range userIDs {
+		if prefs[id] == nil {
+			prefs[id] = auth.DefaultDigestPreference(id)
+		}
+	}
+	return prefs, nil
+}
+
+func (r *digestRepo) ClaimRun(userID uint, localDate time.Time) (bool, error) {
+	res := r.db.Exec(`
+INSERT INTO digest_runs (user_id, local_date, status, attempts, created_at, updated_at)
+VALUES (?, ?, 'sending', 1, NOW(), NOW())
+ON CONFLICT (user_id, local_date) DO UPDATE
+SET status = 'sending', attempts = digest_runs.attempts + 1, error = NULL, updated_at = NOW()
+WHERE digest_runs.status = 'failed' AND digest_runs.attempts < ?`,
+		userID, localDate.Format(localDateLayout), maxDigestAttempts)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (r *digestRepo) FinishRun(userID uint, localDate time.Time, status string, runErr error) error {
+	updates := map[string]interface{}{"status": status, "updated_at": time.Now().UTC()}
+	if runErr != nil {
+		updates["status"] = DigestFailed
+		updates["error"] = runErr.Error()
+	}
+	return r.db.Model(&DigestRun{}).
+		Where("user_id = ? AND local_date = ?", userID, localDate.Format(localDateLayout)).
+		Updates(updates).Error
+}
+
+func (r *digestRepo) NoteSummaries(userID uint, from, to time.Time) ([]string, error) {
+	var summaries []string
+	err := r.db.Table("notes").
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from.UTC(), to.UTC()).
+		Order("created_at").
+		Pluck("summary", &summaries).Error
+	return summaries, err
+}
+
+func (r *digestRepo) DueQuestions(userID uint, before time.Time, limit int) ([]string, int64, error) {
+	query := r.db.Table("review_cards").
+		Where("user_id = ? AND due_at < ?", userID, before.UTC()).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var questions []string
+	err := query.Order("due_at, id").Limit(limit).Pluck("question", &questions).Error
+	return questions, total, err
+}
+
+func (r *digestRepo) OverdueTasks(userID uint, before time.Time, limit int) ([]OverdueTask, int64, error) {
+	query := r.db.Table("tasks").
+		Where("user_id = ? AND completed = false AND due_date < ?", userID, before.Format(localDateLayout)).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var tasks []OverdueTask
+	err := query.Select("text, due_date").Order("due_date, id").Limit(limit).Scan(&tasks).Error
+	return tasks, total, err
+}
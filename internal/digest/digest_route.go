@@ -0,0 +1,21 @@
+package digest
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, digestHandler *DigestHandler, authMiddleware, adminOrCron gin.HandlerFunc) {
+	v1 := router.Group("/api/v1")
+	{
+		// admins or a scheduler signing the request with CRON_SECRET
+		v1.POST("/auth/send-daily-summary", adminOrCron, digestHandler.SendDailySummary)
+	}
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(authMiddleware, token.RequireRole(token.RoleAdmin))
+	{
+		admin.GET("/digest/preview", digestHandler.PreviewDigest)
+	}
+}
@@ -0,0 +1,107 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"notemind/internal/auth"
+	"notemind/internal/mailer"
+	"notemind/internal/render"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var (
+	digestHTML = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/digest.html.tmpl"))
+	digestText = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/digest.txt.tmpl"))
+)
+
+// digestEmail is the data both digest templates are executed with. Message
+// is the raw markdown from the LLM, Body is the same rendered and sanitized.
+type digestEmail struct {
+	Subject        string
+	Name           string
+	Period         string
+	PeriodLower    string
+	Message        string
+	Body           htmltemplate.HTML
+	DueQuestions   []string
+	DueCount       int64
+	OverdueTasks   []OverdueTask
+	OverdueCount   int64
+	UnsubscribeURL string
+}
+
+// Sender turns a generated digest into an email and delivers it.
+type Sender interface {
+	Compose(user auth.User, content Content, message string) (*mailer.Message, error)
+	Send(ctx context.Context, msg mailer.Message) error
+}
+
+type mailSender struct {
+	mail mailer.Mailer
+}
+
+func NewSender(mail mailer.Mailer) Sender {
+	return &mailSender{mail: mail}
+}
+
+func (s *mailSender) Send(ctx context.Context, msg mailer.Message) error {
+	return s.mail.Send(ctx, msg)
+}
+
+// Compose renders the digest for user. The LLM output is treated as
+// untrusted markdown and only reaches the HTML part after sanitizing.
+func (s *mailSender) Compose(user auth.User, content Content, message string) (*mailer.Message, error) {
+	unsubscribeLink, err := auth.UnsubscribeURL(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	body, err := render.Markdown(message)
+	if err != nil {
+		return nil, err
+	}
+
+	data := digestEmail{
+		Subject:        "Your Daily Note Summary 📝",
+		Name:           user.Name,
+		Period:         "Daily",
+		PeriodLower:    "daily",
+		Message:        render.MarkdownText(message),
+		Body:           body,
+		DueQuestions:   content.DueQuestions,
+		DueCount:       content.DueCount,
+		OverdueTasks:   content.OverdueTasks,
+		OverdueCount:   content.OverdueCount,
+		UnsubscribeURL: unsubscribeLink,
+	}
+	if content.Frequency == auth.DigestWeekly {
+		data.Subject = "Your Weekly Note Summary 📝"
+		data.Period = "Weekly"
+		data.PeriodLower = "weekly"
+	}
+
+	var html, text bytes.Buffer
+	if err := digestHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	if err := digestText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	return &mailer.Message{
+		To:      mailer.Address{Email: user.Email, Name: user.Name},
+		Subject: data.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		// Lets mail clients offer their own one-click unsubscribe button.
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeLink + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
@@ -0,0 +1,217 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"notemind/internal/auth"
+	"notemind/internal/llm"
+	"notemind/internal/mailer"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxDueQuestions is how many due review questions a digest quotes.
+	maxDueQuestions = 5
+	// maxOverdueTasks is how many overdue tasks a digest lists.
+	maxOverdueTasks = 10
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+type DigestService interface {
+	// Run sends every digest that is due now and reports what happened to
+	// each user it considered.
+	Run(ctx context.Context) (*DigestRunReport, error)
+	// Preview renders the digest the user would receive right now without
+	// claiming a digest run or sending anything.
+	Preview(ctx context.Context, userID uint) (*mailer.Message, error)
+}
+
+type digestService struct {
+	repo   DigestRepo
+	llm    llm.Client
+	sender Sender
+	now    func() time.Time
+}
+
+func NewDigestService(repo DigestRepo, llm llm.Client, sender Sender) DigestService {
+	return &digestService{repo: repo, llm: llm, sender: sender, now: time.Now}
+}
+
+func (s *digestService) Run(ctx context.Context) (*DigestRunReport, error) {
+	now := s.now()
+	report := &DigestRunReport{StartedAt: now.UTC()}
+
+	users, err := s.repo.ListRecipients()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch users: %w", err)
+	}
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	preferences, err := s.repo.GetPreferences(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch digest preferences: %w", err)
+	}
+
+	timezoneGroups := make(map[string][]auth.User)
+	for _, user := range users {
+		timezoneGroups[user.Timezone] = append(timezoneGroups[user.Timezone], user)
+	}
+
+	for tz, users := range timezoneGroups {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			for _, user := range users {
+				report.add(user, DigestSkipped, fmt.Sprintf("invalid timezone %s", tz))
+			}
+			continue
+		}
+		for _, user := range users {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			status, reason := s.runForUser(ctx, user, preferences[user.ID], now.In(loc))
+			report.add(user, status, reason)
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	log.Printf("digest: total %d, sent %d, failed %d, skipped %d",
+		report.Total, report.Sent, report.Failed, report.Skipped)
+	return report, nil
+}
+
+// runForUser sends the user's digest if it is due at localNow.
+func (s *digestService) runForUser(ctx context.Context, user auth.User, prefs *auth.DigestPreference, localNow time.Time) (string, string) {
+	if prefs.Frequency == auth.DigestOff {
+		return DigestSkipped, "digest turned off"
+	}
+	if prefs.Frequency == auth.DigestWeekly && localNow.Weekday() != time.Weekday(prefs.Weekday) {
+		return DigestSkipped, fmt.Sprintf("weekly digest is sent on %s", time.Weekday(prefs.Weekday))
+	}
+	// Anything past the preferred hour counts as due so a restart or a
+	// missed tick only delays the digest instead of skipping the day.
+	if localNow.Hour() < prefs.SendHour {
+		return DigestSkipped, fmt.Sprintf("not due until %02d:00 %s", prefs.SendHour, localNow.Location())
+	}
+
+	today := startOfDay(localNow)
+	claimed, err := s.repo.ClaimRun(user.ID, today)
+	if err != nil {
+		return DigestFailed, fmt.Sprintf("failed to claim digest run: %v", err)
+	}
+	if !claimed {
+		return DigestSkipped, "already sent today"
+	}
+
+	content, err := s.collect(user.ID, prefs.Frequency, today)
+	if err != nil {
+		s.finish(user.ID, today, DigestFailed, err)
+		return DigestFailed, fmt.Sprintf("failed to get summaries: %v", err)
+	}
+	if content.Empty() && !prefs.IncludeEmpty {
+		s.finish(user.ID, today, DigestSkipped, nil)
+		return DigestSkipped, "no notes in this period"
+	}
+
+	msg, err := s.sender.Compose(user, content, s.generate(ctx, user.ID, content))
+	if err == nil {
+		err = s.sender.Send(ctx, *msg)
+	}
+	s.finish(user.ID, today, DigestSent, err)
+	if err != nil {
+		log.Printf("digest: failed to send to %s: %v", user.Email, err)
+		return DigestFailed, fmt.Sprintf("failed to send email: %v", err)
+	}
+	return DigestSent, ""
+}
+
+func (s *digestService) Preview(ctx context.Context, userID uint) (*mailer.Message, error) {
+	user, err := s.repo.GetUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefs, err := s.repo.GetPreferences([]uint{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.UTC
+	if user.Timezone != "" {
+		if loc, err = time.LoadLocation(user.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %s", user.Timezone)
+		}
+	}
+
+	frequency := prefs[userID].Frequency
+	content, err := s.collect(userID, frequency, startOfDay(s.now().In(loc)))
+	if err != nil {
+		return nil, err
+	}
+	return s.sender.Compose(*user, content, s.generate(ctx, userID, content))
+}
+
+// collect gathers what the digest covers: the notes of the local day for
+// daily digests or of the seven days ending today for weekly ones, the
+// review cards due by the end of today and the tasks due before today.
+func (s *digestService) collect(userID uint, frequency string, today time.Time) (Content, error) {
+	from, to := today, today.AddDate(0, 0, 1)
+	if frequency == auth.DigestWeekly {
+		from = today.AddDate(0, 0, -6)
+	}
+	summaries, err := s.repo.NoteSummaries(userID, from, to)
+	if err != nil {
+		return Content{}, err
+	}
+	questions, due, err := s.repo.DueQuestions(userID, to, maxDueQuestions)
+	if err != nil {
+		return Content{}, err
+	}
+	tasks, overdue, err := s.repo.OverdueTasks(userID, today, maxOverdueTasks)
+	if err != nil {
+		return Content{}, err
+	}
+	return Content{
+		Frequency:    frequency,
+		Summaries:    summaries,
+		DueQuestions: questions,
+		DueCount:     due,
+		OverdueTasks: tasks,
+		OverdueCount: overdue,
+	}, nil
+}
+
+// generate returns the markdown body of the digest. It never fails, the
+// user gets a fallback message when the LLM does, or when they are out of
+// quota.
+func (s *digestService) generate(ctx context.Context, userID uint, content Content) string {
+	if len(content.Summaries) == 0 {
+		return quietMessage(content.Frequency)
+	}
+	res, err := s.llm.Generate(llm.ForUser(ctx, userID, llm.FeatureDigest), BuildPrompt(content))
+	if err != nil || res == "" {
+		log.Printf("digest: failed to generate summary: %v", err)
+		return fallbackMessage(content.Frequency)
+	}
+	return res
+}
+
+func (s *digestService) finish(userID uint, localDate time.Time, status string, runErr error) {
+	if err := s.repo.FinishRun(userID, localDate, status, runErr); err != nil {
+		log.Printf("digest: failed to record digest run for user %d: %v", userID, err)
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,267 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"notemind/internal/auth"
+	"notemind/internal/llm"
+	"notemind/internal/mailer"
+)
+
+type fakeRepo struct {
+	users     []auth.User
+	prefs     map[uint]*auth.DigestPreference
+	summaries map[uint][]string
+	questions map[uint][]string
+
+	claimed  map[uint]bool
+	finished map[uint]string
+}
+
+func newFakeRepo(users ...auth.User) *fakeRepo {
+	return &fakeRepo{
+		users:     users,
+		prefs:     map[uint]*auth.DigestPreference{},
+		summaries: map[uint][]string{},
+		questions: map[uint][]string{},
+		claimed:   map[uint]bool{},
+		finished:  map[uint]string{},
+	}
+}
+
+func (r *fakeRepo) ListRecipients() ([]auth.User, error) { return r.users, nil }
+
+func (r *fakeRepo) GetUser(userID uint) (*auth.User, error) {
+	for i := range r.users {
+		if r.users[i].ID == userID {
+			return &r.users[i], nil
+		}
+	}
+	return nil, errors.New("not found")
+}
+
+func (r *fakeRepo) GetPreferences(userIDs []uint) (map[uint]*auth.DigestPreference, error) {
+	prefs := make(map[uint]*auth.DigestPreference, len(userIDs))
+	for _, id := range userIDs {
+		prefs[id] = r.prefs[id]
+		if prefs[id] == nil {
+			prefs[id] = auth.DefaultDigestPreference(id)
+		}
+	}
+	return prefs, nil
+}
+
+func (r *fakeRepo) ClaimRun(userID uint, localDate time.Time) (bool, error) {
+	if r.claimed[userID] {
+		return false, nil
+	}
+	r.claimed[userID] = true
+	return true, nil
+}
+
+func (r *fakeRepo) FinishRun(userID uint, localDate time.Time, status string, runErr error) error {
+	if runErr != nil {
+		status = DigestFailed
+	}
+	r.finished[userID] = status
+	return nil
+}
+
+func (r *fakeRepo) NoteSummaries(userID uint, from, to time.Time) ([]string, error) {
+	return r.summaries[userID], nil
+}
+
+func (r *fakeRepo) DueQuestions(userID uint, before time.Time, limit int) ([]string, int64, error) {
+	return r.questions[userID], int64(len(r.questions[userID])), nil
+}
+
+func (r *fakeRepo) OverdueTasks(userID uint, before time.Time, limit int) ([]OverdueTask, int64, error) {
+	return nil, 0, nil
+}
+
+type fakeSender struct {
+	sent    []mailer.Message
+	sendErr error
+}
+
+func (s *fakeSender) Compose(user auth.User, content Content, message string) (*mailer.Message, error) {
+	return &mailer.Message{To: mailer.Address{Email: user.Email, Name: user.Name}, Text: message}, nil
+}
+
+func (s *fakeSender) Send(ctx context.Context, msg mailer.Message) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// mondayEvening is a Monday at 21:00 UTC, past the default send hour.
+var mondayEvening = time.Date(2026, time.October, 19, 21, 0, 0, 0, time.UTC)
+
+func newTestService(repo *fakeRepo, fake *llm.Fake, sender *fakeSender) *digestService {
+	return &digestService{repo: repo, llm: fake, sender: sender, now: func() time.Time { return mondayEvening }}
+}
+
+func testUser(id uint) auth.User {
+	return auth.User{ID: id, Name: "Ada", Email: "ada@example.com", Timezone: "UTC"}
+}
+
+func TestRunSendsGeneratedDigest(t *testing.T) {
+	repo := newFakeRepo(testUser(1))
+	repo.summaries[1] = []string{"Learned about B-trees."}
+	repo.questions[1] = []string{"What is the fan-out of a B-tree?"}
+	fake := &llm.Fake{Response: "## Your day\nB-trees!"}
+	sender := &fakeSender{}
+
+	report, err := newTestService(repo, fake, sender).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Sent != 1 || report.Total != 1 {
+		t.Fatalf("report = %+v, want one sent digest", report)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].Text != fake.Response {
+		t.Fatalf("sent %+v, want the generated digest", sender.sent)
+	}
+	if repo.finished[1] != DigestSent {
+		t.Errorf("run finished as %q, want %q", repo.finished[1], DigestSent)
+	}
+
+	prompts := fake.Prompts()
+	if len(prompts) != 1 {
+		t.Fatalf("got %d prompts, want 1", len(prompts))
+	}
+	for _, want := range []string{
+		llm.Untrusted("summaries", "Learned about B-trees."),
+		"What is the fan-out of a B-tree?",
+		llm.UntrustedNotice,
+	} {
+		if !strings.Contains(prompts[0], want) {
+			t.Errorf("prompt does not contain %q", want)
+		}
+	}
+}
+
+func TestRunSkipsDigestsThatAreNotDue(t *testing.T) {
+	tests := []struct {
+		name      string
+		prefs     auth.DigestPreference
+		summaries []string
+	}{
+		{"turned off", auth.DigestPreference{Frequency: auth.DigestOff}, []string{"A note."}},
+		{"before send hour", auth.DigestPreference{Frequency: auth.DigestDaily, SendHour: 22}, []string{"A note."}},
+		{"weekly on another day", auth.DigestPreference{Frequency: auth.DigestWeekly, SendHour: 8, Weekday: int(time.Friday)}, []string{"A note."}},
+		{"nothing to say", auth.DigestPreference{Frequency: auth.DigestDaily, SendHour: 8}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepo(testUser(1))
+			prefs := tt.prefs
+			repo.prefs[1] = &prefs
+			repo.summaries[1] = tt.summaries
+			fake := &llm.Fake{Response: "digest"}
+			sender := &fakeSender{}
+
+			report, err := newTestService(repo, fake, sender).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if report.Skipped != 1 {
+				t.Errorf("report = %+v, want the digest skipped", report)
+			}
+			if len(sender.sent) != 0 || len(fake.Prompts()) != 0 {
+				t.Errorf("sent %d emails with %d prompts, want none", len(sender.sent), len(fake.Prompts()))
+			}
+		})
+	}
+}
+
+func TestRunSendsOnceADay(t *testing.T) {
+	repo := newFakeRepo(testUser(1))
+	repo.summaries[1] = []string{"A note."}
+	sender := &fakeSender{}
+	service := newTestService(repo, &llm.Fake{Response: "digest"}, sender)
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.Run(context.Background()); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+	if len(sender.sent) != 1 {
+		t.Errorf("sent %d digests, want 1", len(sender.sent))
+	}
+}
+
+func TestRunFallsBackWhenLLMFails(t *testing.T) {
+	for _, llmErr := range []error{llm.ErrQuotaExceeded, llm.ErrBlocked, errors.New("model unavailable")} {
+		t.Run(llmErr.Error(), func(t *testing.T) {
+			repo := newFakeRepo(testUser(1))
+			repo.summaries[1] = []string{"A note."}
+			sender := &fakeSender{}
+
+			report, err := newTestService(repo, &llm.Fake{Err: llmErr}, sender).Run(context.Background())
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if report.Sent != 1 || len(sender.sent) != 1 {
+				t.Fatalf("report = %+v, want the digest sent anyway", report)
+			}
+			if sender.sent[0].Text != fallbackMessage(auth.DigestDaily) {
+				t.Errorf("sent %q, want the fallback message", sender.sent[0].Text)
+			}
+		})
+	}
+}
+
+func TestRunRecordsSendFailures(t *testing.T) {
+	repo := newFakeRepo(testUser(1))
+	repo.summaries[1] = []string{"A note."}
+	sender := &fakeSender{sendErr: errors.New("smtp down")}
+
+	report, err := newTestService(repo, &llm.Fake{Response: "digest"}, sender).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Failed != 1 {
+		t.Errorf("report = %+v, want the digest failed", report)
+	}
+	if repo.finished[1] != DigestFailed {
+		t.Errorf("run finished as %q, want %q", repo.finished[1], DigestFailed)
+	}
+}
+
+func TestPreviewDoesNotClaimOrSend(t *testing.T) {
+	repo := newFakeRepo(testUser(1))
+	sender := &fakeSender{}
+	fake := &llm.Fake{Response: "digest"}
+
+	msg, err := newTestService(repo, fake, sender).Preview(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	// without notes the quiet day message is used and the LLM not called
+	if msg.Text != quietMessage(auth.DigestDaily) || len(fake.Prompts()) != 0 {
+		t.Errorf("preview = %q after %d prompts, want the quiet day message", msg.Text, len(fake.Prompts()))
+	}
+	if repo.claimed[1] || len(sender.sent) != 0 {
+		t.Error("preview claimed a run or sent an email")
+	}
+}
+
+func TestPreviewQuietWeek(t *testing.T) {
+	repo := newFakeRepo(testUser(1))
+	repo.prefs[1] = &auth.DigestPreference{Frequency: auth.DigestWeekly, SendHour: 8}
+
+	msg, err := newTestService(repo, &llm.Fake{}, &fakeSender{}).Preview(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if !strings.Contains(msg.Text, "Your Weekly Reflection") || strings.Contains(msg.Text, "Today") {
+		t.Errorf("preview = %q, want the weekly quiet message", msg.Text)
+	}
+}
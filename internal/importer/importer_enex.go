@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"notemind/internal/note"
+)
+
+const enexTimeLayout = "20060102T150405Z"
+
+var (
+	enexMedia   = regexp.MustCompile(`(?s)<en-media\b([^>]*?)/?>(\s*</en-media>)?`)
+	enexTodo    = regexp.MustCompile(`(?s)<en-todo\b([^>]*?)/?>(\s*</en-todo>)?`)
+	enexHash    = regexp.MustCompile(`\bhash="([0-9a-fA-F]+)"`)
+	enexChecked = regexp.MustCompile(`\bchecked="true"`)
+)
+
+// enexNote is a note of an Evernote export.
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data     string `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// parseEnex imports the notes of an Evernote .enex file.
+func parseEnex(r io.Reader, fn visit) error {
+	dec := xml.NewDecoder(r)
+	// ENEX files declare a DTD the decoder does not need to resolve
+	dec.Strict = false
+	index := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return formatError(fmt.Sprintf("not a valid Evernote export: %v", err))
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+		index++
+		var n enexNote
+		if err := dec.DecodeElement(&n, &start); err != nil {
+			return formatError(fmt.Sprintf("not a valid Evernote export: %v", err))
+		}
+		if !fn(n.entry(index)) {
+			return nil
+		}
+	}
+}
+
+func (n *enexNote) entry(index int) entry {
+	e := entry{name: strings.TrimSpace(n.Title)}
+	if e.name == "" {
+		e.name = fmt.Sprintf("note %d", index)
+	}
+
+	imported := &note.ImportedNote{
+		Title:  strings.TrimSpace(n.Title),
+		Format: note.FormatHTML,
+		Tags:   n.Tags,
+	}
+	var err error
+	if imported.CreatedAt, err = parseEnexTime(n.Created); err != nil {
+		e.err = err
+		return e
+	}
+	if imported.UpdatedAt, err = parseEnexTime(n.Updated); err != nil {
+		e.err = err
+		return e
+	}
+
+	// en-media tags refer to resources by the MD5 of their data
+	refs := make(map[string]string)
+	skipped := 0
+	for _, res := range n.Resources {
+		if !strings.HasPrefix(res.Mime, "image/") || strings.Contains(res.Mime, "svg") {
+			skipped++
+			continue
+		}
+		if len(imported.Images) == maxNoteImages {
+			e.warnings = append(e.warnings, fmt.Sprintf("only the first %d images are imported", maxNoteImages))
+			break
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(res.Data), ""))
+		if err != nil {
+			e.warnings = append(e.warnings, fmt.Sprintf("image %s could not be decoded", res.FileName))
+			continue
+		}
+		if len(data) > maxImageBytes {
+			e.warnings = append(e.warnings, fmt.Sprintf("image %s: %v", res.FileName, errTooLarge))
+			continue
+		}
+		sum := md5.Sum(data)
+		ref := imageRef(len(imported.Images))
+		refs[hex.EncodeToString(sum[:])] = ref
+		imported.Images = append(imported.Images, note.ImportedImage{Ref: ref, Data: data})
+	}
+	switch {
+	case skipped == 1:
+		e.warnings = append(e.warnings, "an attachment that is not an image was left out")
+	case skipped > 1:
+		e.warnings = append(e.warnings, fmt.Sprintf("%d attachments that are not images were left out", skipped))
+	}
+
+	content := enmlBody(n.Content)
+	if len(content) > maxNoteBytes {
+		e.err = errTooLarge
+		return e
+	}
+	content = enexMedia.ReplaceAllStringFunc(content, func(tag string) string {
+		m := enexHash.FindStringSubmatch(tag)
+		if m == nil {
+			return ""
+		}
+		if ref, ok := refs[strings.ToLower(m[1])]; ok {
+			return `<img src="` + ref + `">`
+		}
+		return ""
+	})
+	imported.Content = enexTodo.ReplaceAllStringFunc(content, func(tag string) string {
+		if enexChecked.MatchString(tag) {
+			return "[x] "
+		}
+		return "[ ] "
+	})
+	if imported.Title == "" {
+		imported.Title = "Untitled note"
+	}
+	e.note = imported
+	return e
+}
+
+// enmlBody returns what is inside the en-note element of an ENML document.
+func enmlBody(enml string) string {
+	start := strings.Index(enml, "<en-note")
+	if start < 0 {
+		return enml
+	}
+	open := strings.Index(enml[start:], ">")
+	if open < 0 {
+		return ""
+	}
+	body := enml[start+open+1:]
+	if end := strings.LastIndex(body, "</en-note>"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimSpace(body)
+}
+
+func parseEnexTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(enexTimeLayout, value)
+	if err != nil {
+		return time.Time{}, formatError(fmt.Sprintf("unrecognised date %q", value))
+	}
+	return t, nil
+}
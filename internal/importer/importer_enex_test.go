@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"notemind/internal/note"
+)
+
+// enexFile wraps notes in an export as Evernote writes it.
+func enexFile(notes ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20240301T120000Z">` + strings.Join(notes, "\n") + `</en-export>`
+}
+
+func enexContent(body string) string {
+	return `<content><![CDATA[<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note>` + body + `</en-note>]]></content>`
+}
+
+func parseEnexString(t *testing.T, src string) ([]entry, error) {
+	t.Helper()
+	var entries []entry
+	err := parseEnex(strings.NewReader(src), func(e entry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	return entries, err
+}
+
+func TestParseEnex(t *testing.T) {
+	img := []byte("\x89PNG fake image")
+	sum := md5.Sum(img)
+	hash := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		note     string
+		want     *note.ImportedNote
+		warnings int
+		err      bool
+	}{
+		{
+			name: "text and tags",
+			note: `<note><title> Groceries </title>` + enexContent(`<div>Milk</div>`) +
+				`<created>20240301T101500Z</created><updated>20240302T090000Z</updated><tag>home</tag><tag>food</tag></note>`,
+			want: &note.ImportedNote{
+				Title:     "Groceries",
+				Content:   "<div>Milk</div>",
+				Format:    note.FormatHTML,
+				Tags:      []string{"home", "food"},
+				CreatedAt: time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC),
+				UpdatedAt: time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "checklist",
+			note: `<note><title>Todo</title>` + enexContent(`<div><en-todo checked="true"/>done</div><div><en-todo></en-todo>open</div>`) + `</note>`,
+			want: &note.ImportedNote{Title: "Todo", Content: "<div>[x] done</div><div>[ ] open</div>", Format: note.FormatHTML},
+		},
+		{
+			name: "images and attachments",
+			note: `<note><title>Scan</title>` +
+				enexContent(fmt.Sprintf(`<en-media type="image/png" hash="%s"/><en-media type="application/pdf" hash="ffff"></en-media>`, strings.ToUpper(hash))) +
+				`<resource><data encoding="base64">` + base64.StdEncoding.EncodeToString(img) + `</data><mime>image/png</mime></resource>` +
+				`<resource><data encoding="base64">JVBERi0=</data><mime>application/pdf</mime></resource></note>`,
+			want: &note.ImportedNote{
+				Title:   "Scan",
+				Content: `<img src="` + imageRef(0) + `">`,
+				Format:  note.FormatHTML,
+				Images:  []note.ImportedImage{{Ref: imageRef(0), Data: img}},
+			},
+			warnings: 1,
+		},
+		{
+			name: "untitled",
+			note: `<note><title></title>` + enexContent(`text`) + `</note>`,
+			want: &note.ImportedNote{Title: "Untitled note", Content: "text", Format: note.FormatHTML},
+		},
+		{
+			name: "invalid date",
+			note: `<note><title>A</title>` + enexContent(`text`) + `<created>yesterday</created></note>`,
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := parseEnexString(t, enexFile(tt.note))
+			if err != nil {
+				t.Fatalf("parseEnex: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+			e := entries[0]
+			if tt.err {
+				var fe formatError
+				if !errors.As(e.err, &fe) {
+					t.Fatalf("err = %v, want a format error", e.err)
+				}
+				return
+			}
+			if e.err != nil {
+				t.Fatalf("err = %v", e.err)
+			}
+			if !reflect.DeepEqual(e.note, tt.want) {
+				t.Errorf("note = %+v, want %+v", e.note, tt.want)
+			}
+			if len(e.warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", e.warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestParseEnexNamesUntitledNotes(t *testing.T) {
+	entries, err := parseEnexString(t, enexFile(
+		`<note><title>First</title>`+enexContent(`a`)+`</note>`,
+		`<note><title/>`+enexContent(`b`)+`</note>`,
+	))
+	if err != nil {
+		t.Fatalf("parseEnex: %v", err)
+	}
+	if len(entries) != 2 || entries[0].name != "First" || entries[1].name != "note 2" {
+		t.Errorf("entries = %+v, want First and note 2", entries)
+	}
+}
+
+func TestParseEnexRejectsInvalidFiles(t *testing.T) {
+	_, err := parseEnexString(t, `<en-export><note><title>A</title><content>`)
+	var fe formatError
+	if !errors.As(err, &fe) {
+		t.Errorf("err = %v, want a format error", err)
+	}
+}
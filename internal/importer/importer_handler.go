@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	importService ImportService
+}
+
+func NewImportHandler(importService ImportService) *ImportHandler {
+	return &ImportHandler{importService: importService}
+}
+
+// StartImport takes a multipart "file" and an optional "source" of
+// markdown, enex or keep, and imports its notes in the background.
+func (h *ImportHandler) StartImport(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	job, err := h.importService.Start(user.UserID, file, ctx.PostForm("source"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrImportInProgress):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "import": job})
+		case errors.Is(err, ErrFileTooLarge):
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrUnknownSource), errors.Is(err, ErrUnsupportedFile), errors.Is(err, ErrInvalidArchive):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":    "Import started",
+		"import":     job,
+		"status_url": fmt.Sprintf("/api/v1/import/%d", job.ID),
+	})
+}
+
+// GetImport reports the progress of an import and the result of each note.
+func (h *ImportHandler) GetImport(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	jobID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || jobID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid import ID"})
+		return
+	}
+
+	job, err := h.importService.GetJob(user.UserID, uint(jobID))
+	if err != nil {
+		if errors.Is(err, ErrImportNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"import": job})
+}
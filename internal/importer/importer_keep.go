@@ -0,0 +1,165 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"notemind/internal/note"
+)
+
+// keepNote is a note of a Google Keep Takeout archive, one JSON file each.
+type keepNote struct {
+	Title       string `json:"title"`
+	TextContent string `json:"textContent"`
+	ListContent []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Attachments []struct {
+		FilePath string `json:"filePath"`
+		Mimetype string `json:"mimetype"`
+	} `json:"attachments"`
+	IsTrashed               bool  `json:"isTrashed"`
+	CreatedTimestampUsec    int64 `json:"createdTimestampUsec"`
+	UserEditedTimestampUsec int64 `json:"userEditedTimestampUsec"`
+}
+
+// isKeepArchive reports whether a ZIP is a Keep Takeout rather than a
+// folder of Markdown files.
+func isKeepArchive(files *archive) bool {
+	for _, name := range files.names {
+		if isKeepNote(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isKeepNote(name string) bool {
+	return path.Base(path.Dir(name)) == "Keep" && strings.EqualFold(path.Ext(name), ".json")
+}
+
+// parseKeepArchive imports every note of a Keep Takeout.
+func parseKeepArchive(files *archive, fn visit) error {
+	for _, name := range files.names {
+		if !isKeepNote(name) {
+			continue
+		}
+		data, err := files.read(name, maxNoteBytes)
+		if errors.Is(err, errArchiveBudget) {
+			return err
+		}
+		e := entry{name: name, err: err}
+		if err == nil {
+			e = parseKeepNote(name, data, files)
+			if errors.Is(e.err, errArchiveBudget) {
+				return e.err
+			}
+		}
+		if !fn(e) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func parseKeepNote(name string, data []byte, files *archive) entry {
+	e := entry{name: name}
+	var k keepNote
+	if err := json.Unmarshal(data, &k); err != nil {
+		e.err = formatError("not a Keep note")
+		return e
+	}
+	if k.IsTrashed {
+		e.skip = "note is in the Keep trash"
+		return e
+	}
+
+	var content strings.Builder
+	content.WriteString(strings.TrimSpace(k.TextContent))
+	for _, item := range k.ListContent {
+		if content.Len() > 0 {
+			content.WriteString("\n")
+		}
+		if item.IsChecked {
+			content.WriteString("[x] ")
+		} else {
+			content.WriteString("[ ] ")
+		}
+		content.WriteString(item.Text)
+	}
+
+	n := &note.ImportedNote{
+		Title:     strings.TrimSpace(k.Title),
+		Content:   content.String(),
+		Format:    note.FormatPlain,
+		CreatedAt: keepTime(k.CreatedTimestampUsec),
+		UpdatedAt: keepTime(k.UserEditedTimestampUsec),
+	}
+	if n.Title == "" {
+		n.Title = titleFromName(name)
+	}
+	for _, label := range k.Labels {
+		n.Tags = append(n.Tags, label.Name)
+	}
+
+	for _, att := range k.Attachments {
+		if !strings.HasPrefix(att.Mimetype, "image/") {
+			continue
+		}
+		if len(n.Images) == maxNoteImages {
+			e.warnings = append(e.warnings, fmt.Sprintf("only the first %d images are imported", maxNoteImages))
+			break
+		}
+		file, ok := keepAttachment(files, path.Join(path.Dir(name), att.FilePath))
+		if !ok {
+			e.warnings = append(e.warnings, fmt.Sprintf("image %s not found", att.FilePath))
+			continue
+		}
+		img, err := files.read(file, maxImageBytes)
+		if errors.Is(err, errArchiveBudget) {
+			e.err = err
+			return e
+		}
+		if err != nil {
+			e.warnings = append(e.warnings, fmt.Sprintf("image %s: %v", att.FilePath, err))
+			continue
+		}
+		n.Images = append(n.Images, note.ImportedImage{Data: img})
+	}
+	if n.Content == "" && len(n.Images) == 0 {
+		e.skip = "note is empty"
+		return e
+	}
+	e.note = n
+	return e
+}
+
+// keepAttachment finds an attachment, which Takeout sometimes stores with
+// another extension than the note names, such as .jpg for .jpeg.
+func keepAttachment(files *archive, name string) (string, bool) {
+	if _, ok := files.files[name]; ok {
+		return name, true
+	}
+	base := strings.TrimSuffix(name, path.Ext(name))
+	for ext := range imageExts {
+		if _, ok := files.files[base+ext]; ok {
+			return base + ext, true
+		}
+	}
+	return "", false
+}
+
+func keepTime(usec int64) time.Time {
+	if usec <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(usec).UTC()
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"notemind/internal/note"
+)
+
+func TestParseKeepNote(t *testing.T) {
+	files := testArchive(t, map[string]string{
+		"Takeout/Keep/photo.jpg": "photo",
+	})
+	tests := []struct {
+		name     string
+		src      string
+		want     *note.ImportedNote
+		skip     bool
+		warnings int
+		err      bool
+	}{
+		{
+			name: "text",
+			src: `{"title":" Ideas ","textContent":"  Write more tests \n","labels":[{"name":"work"}],
+				"createdTimestampUsec":1709288100000000,"userEditedTimestampUsec":1709370000000000}`,
+			want: &note.ImportedNote{
+				Title:     "Ideas",
+				Content:   "Write more tests",
+				Format:    note.FormatPlain,
+				Tags:      []string{"work"},
+				CreatedAt: time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC),
+				UpdatedAt: time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "list",
+			src:  `{"listContent":[{"text":"Milk","isChecked":true},{"text":"Eggs"}]}`,
+			want: &note.ImportedNote{Title: "Groceries", Content: "[x] Milk\n[ ] Eggs", Format: note.FormatPlain},
+		},
+		{
+			name: "attachments",
+			src: `{"title":"Trip","attachments":[{"filePath":"photo.jpeg","mimetype":"image/jpeg"},
+				{"filePath":"gone.png","mimetype":"image/png"},{"filePath":"voice.3gp","mimetype":"audio/3gpp"}]}`,
+			want: &note.ImportedNote{
+				Title:  "Trip",
+				Format: note.FormatPlain,
+				Images: []note.ImportedImage{{Data: []byte("photo")}},
+			},
+			warnings: 1,
+		},
+		{name: "trashed", src: `{"title":"Old","textContent":"x","isTrashed":true}`, skip: true},
+		{name: "empty", src: `{"title":"Nothing"}`, skip: true},
+		{name: "not a note", src: `[1, 2]`, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := parseKeepNote("Takeout/Keep/Groceries.json", []byte(tt.src), files)
+			switch {
+			case tt.err:
+				var fe formatError
+				if !errors.As(e.err, &fe) {
+					t.Fatalf("err = %v, want a format error", e.err)
+				}
+				return
+			case e.err != nil:
+				t.Fatalf("err = %v", e.err)
+			case tt.skip:
+				if e.skip == "" || e.note != nil {
+					t.Errorf("entry = %+v, want it skipped", e)
+				}
+				return
+			}
+			if !reflect.DeepEqual(e.note, tt.want) {
+				t.Errorf("note = %+v, want %+v", e.note, tt.want)
+			}
+			if len(e.warnings) != tt.warnings {
+				t.Errorf("warnings = %q, want %d", e.warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestParseKeepArchive(t *testing.T) {
+	files := testArchive(t, map[string]string{
+		"Takeout/Keep/a.json":            `{"textContent":"a"}`,
+		"Takeout/Keep/Labels.txt":        "work",
+		"Takeout/Drive/b.json":           `{"textContent":"b"}`,
+		"__MACOSX/Takeout/Keep/._a.json": "",
+	})
+	if !isKeepArchive(files) {
+		t.Fatal("isKeepArchive = false, want true")
+	}
+	var names []string
+	err := parseKeepArchive(files, func(e entry) bool {
+		names = append(names, e.name)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("parseKeepArchive: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"Takeout/Keep/a.json"}) {
+		t.Errorf("imported %q, want only the Keep note", names)
+	}
+
+	if isKeepArchive(testArchive(t, map[string]string{"notes/a.md": "a"})) {
+		t.Error("a Markdown archive is taken for a Keep Takeout")
+	}
+}
@@ -0,0 +1,257 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"notemind/internal/note"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// markdownImage matches ![alt](target "title"), capturing the target.
+	markdownImage = regexp.MustCompile(`!\[[^\]]*\]\(\s*(<[^>]*>|[^)\s]+)(?:\s+"[^"]*")?\s*\)`)
+	headingTitle  = regexp.MustCompile(`^#[ \t]+(.+?)[ \t#]*$`)
+)
+
+// timeLayouts are the date formats accepted in front matter.
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// frontMatter is the YAML header of a Markdown note, as written by our own
+// export and by apps such as Obsidian.
+type frontMatter struct {
+	Title     string  `yaml:"title"`
+	Tags      tagList `yaml:"tags"`
+	CreatedAt string  `yaml:"created_at"`
+	UpdatedAt string  `yaml:"updated_at"`
+	Created   string  `yaml:"created"`
+	Updated   string  `yaml:"updated"`
+	Date      string  `yaml:"date"`
+	// ContentFormat and Images are written by our own export.
+	ContentFormat string   `yaml:"content_format"`
+	Images        []string `yaml:"images"`
+}
+
+// tagList accepts tags as a YAML list or a comma separated string.
+type tagList []string
+
+func (t *tagList) UnmarshalYAML(value *yaml.Node) error {
+	var names []string
+	switch value.Kind {
+	case yaml.SequenceNode:
+		if err := value.Decode(&names); err != nil {
+			return err
+		}
+	case yaml.ScalarNode:
+		if value.Tag == "!!null" {
+			return nil
+		}
+		names = strings.Split(value.Value, ",")
+	default:
+		return errors.New("tags must be a list")
+	}
+	for i, name := range names {
+		names[i] = strings.TrimPrefix(strings.TrimSpace(name), "#")
+	}
+	*t = names
+	return nil
+}
+
+// parseTime reads a front matter date, the zero time when there is none.
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, formatError(fmt.Sprintf("unrecognised date %q", value))
+}
+
+// splitFrontMatter separates a YAML front matter block from the body.
+func splitFrontMatter(src string) (header, body string) {
+	if !strings.HasPrefix(src, "---\n") {
+		return "", src
+	}
+	rest := src[len("---\n"):]
+	for _, end := range []string{"\n---\n", "\n...\n"} {
+		if i := strings.Index(rest, end); i >= 0 {
+			return rest[:i], rest[i+len(end):]
+		}
+	}
+	if strings.HasSuffix(rest, "\n---") {
+		return strings.TrimSuffix(rest, "\n---"), ""
+	}
+	return "", src
+}
+
+// parseMarkdown turns a Markdown file into a note. Images it links to by a
+// relative path are taken from files, when it is an archive.
+func parseMarkdown(name string, data []byte, files *archive) entry {
+	e := entry{name: name}
+	src := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+
+	header, body := splitFrontMatter(src)
+	var fm frontMatter
+	if header != "" {
+		if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+			e.err = formatError("invalid front matter")
+			return e
+		}
+	}
+	created, err := parseTime(firstOf(fm.CreatedAt, fm.Created, fm.Date))
+	if err != nil {
+		e.err = err
+		return e
+	}
+	updated, err := parseTime(firstOf(fm.UpdatedAt, fm.Updated))
+	if err != nil {
+		e.err = err
+		return e
+	}
+
+	title := strings.TrimSpace(fm.Title)
+	body = strings.TrimLeft(body, "\n")
+	if title == "" {
+		// a leading heading is the title, as in files without front matter
+		firstLine, rest, _ := strings.Cut(body, "\n")
+		if m := headingTitle.FindStringSubmatch(firstLine); m != nil {
+			title, body = m[1], rest
+		}
+	}
+	if title == "" {
+		title = titleFromName(name)
+	}
+
+	n := &note.ImportedNote{
+		Title:     title,
+		Format:    note.FormatMarkdown,
+		Tags:      fm.Tags,
+		CreatedAt: created,
+		UpdatedAt: updated,
+	}
+	if note.ValidFormat(fm.ContentFormat) {
+		n.Format = fm.ContentFormat
+	}
+	var fail error
+	n.Content = markdownImage.ReplaceAllStringFunc(strings.TrimSpace(body), func(link string) string {
+		target := markdownImage.FindStringSubmatch(link)[1]
+		file, ok := localImage(name, target)
+		if !ok || files == nil || fail != nil {
+			return link
+		}
+		if len(n.Images) == maxNoteImages {
+			e.warnings = append(e.warnings, fmt.Sprintf("only the first %d images are imported", maxNoteImages))
+			return link
+		}
+		img, err := files.read(file, maxImageBytes)
+		switch {
+		case errors.Is(err, errArchiveBudget):
+			fail = err
+			return link
+		case err != nil:
+			e.warnings = append(e.warnings, fmt.Sprintf("image %s: %v", file, err))
+			return link
+		}
+		ref := imageRef(len(n.Images))
+		n.Images = append(n.Images, note.ImportedImage{Ref: ref, Data: img})
+		return strings.Replace(link, target, ref, 1)
+	})
+	if fail != nil {
+		e.err = fail
+		return e
+	}
+
+	// images of the note that its content does not show
+	remote := 0
+	for _, src := range fm.Images {
+		file, ok := localImage(name, src)
+		if !ok || files == nil {
+			remote++
+			continue
+		}
+		if len(n.Images) == maxNoteImages {
+			e.warnings = append(e.warnings, fmt.Sprintf("only the first %d images are imported", maxNoteImages))
+			break
+		}
+		img, err := files.read(file, maxImageBytes)
+		switch {
+		case errors.Is(err, errArchiveBudget):
+			e.err = err
+			return e
+		case err != nil:
+			e.warnings = append(e.warnings, fmt.Sprintf("image %s: %v", file, err))
+			continue
+		}
+		n.Images = append(n.Images, note.ImportedImage{Data: img})
+	}
+	switch {
+	case remote == 1:
+		e.warnings = append(e.warnings, "an image listed by URL in the front matter was not copied")
+	case remote > 1:
+		e.warnings = append(e.warnings, fmt.Sprintf("%d images listed by URL in the front matter were not copied", remote))
+	}
+	e.note = n
+	return e
+}
+
+// localImage resolves the link target of an image relative to the Markdown
+// file name, reporting false for remote and non-image targets.
+func localImage(name, target string) (string, bool) {
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	if strings.Contains(target, ":") || strings.HasPrefix(target, "/") {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	file := path.Join(path.Dir(name), target)
+	if strings.HasPrefix(file, "../") || !isImage(file) {
+		return "", false
+	}
+	return file, true
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseMarkdownArchive imports every Markdown file of a ZIP.
+func parseMarkdownArchive(files *archive, fn visit) error {
+	for _, name := range files.names {
+		switch strings.ToLower(path.Ext(name)) {
+		case ".md", ".markdown":
+		default:
+			continue
+		}
+		data, err := files.read(name, maxNoteBytes)
+		if errors.Is(err, errArchiveBudget) {
+			return err
+		}
+		e := entry{name: name, err: err}
+		if err == nil {
+			e = parseMarkdown(name, data, files)
+			if errors.Is(e.err, errArchiveBudget) {
+				return e.err
+			}
+		}
+		if !fn(e) {
+			return nil
+		}
+	}
+	return nil
+}
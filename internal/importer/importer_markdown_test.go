@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"notemind/internal/note"
+)
+
+// testArchive builds an archive of files, names mapped to content.
+func testArchive(t *testing.T, files map[string]string) *archive {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newArchive(zr)
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name, src, header, body string
+	}{
+		{"none", "# Title\ntext", "", "# Title\ntext"},
+		{"dashes", "---\ntitle: A\n---\nbody", "title: A", "body"},
+		{"dots", "---\ntitle: A\n...\nbody", "title: A", "body"},
+		{"header only", "---\ntitle: A\n---", "title: A", ""},
+		{"unclosed", "---\ntitle: A\nbody", "", "---\ntitle: A\nbody"},
+		{"rule later on", "text\n---\nmore", "", "text\n---\nmore"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, body := splitFrontMatter(tt.src)
+			if header != tt.header || body != tt.body {
+				t.Errorf("splitFrontMatter(%q) = %q, %q, want %q, %q", tt.src, header, body, tt.header, tt.body)
+			}
+		})
+	}
+}
+
+func TestLocalImage(t *testing.T) {
+	tests := []struct {
+		name, file, target, want string
+		ok                       bool
+	}{
+		{"sibling", "notes/a.md", "b.png", "notes/b.png", true},
+		{"parent", "notes/a.md", "../images/1/2.jpg", "images/1/2.jpg", true},
+		{"angle brackets", "a.md", "<my image.png>", "my image.png", true},
+		{"escaped", "a.md", "my%20image.PNG", "my image.PNG", true},
+		{"remote", "a.md", "https://example.com/a.png", "", false},
+		{"absolute", "a.md", "/etc/a.png", "", false},
+		{"outside the archive", "notes/a.md", "../../a.png", "", false},
+		{"not an image", "a.md", "doc.pdf", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := localImage(tt.file, tt.target)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("localImage(%q, %q) = %q, %v, want %q, %v", tt.file, tt.target, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name, file, src string
+		want            *note.ImportedNote
+		err             bool
+	}{
+		{
+			name: "front matter",
+			file: "a.md",
+			src:  "---\ntitle: Groceries\ntags: [home, \"#errands\"]\ncreated_at: 2024-03-01T10:00:00Z\nupdated: 2024-03-02\ncontent_format: plain\n---\n\nMilk\r\n",
+			want: &note.ImportedNote{
+				Title:     "Groceries",
+				Content:   "Milk",
+				Format:    note.FormatPlain,
+				Tags:      []string{"home", "errands"},
+				CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+				UpdatedAt: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "comma separated tags",
+			file: "a.md",
+			src:  "---\ntitle: A\ntags: work, ideas\n---\ntext",
+			want: &note.ImportedNote{Title: "A", Content: "text", Format: note.FormatMarkdown, Tags: []string{"work", "ideas"}},
+		},
+		{
+			name: "heading title",
+			file: "a.md",
+			src:  "\ufeff# Trip ##\n\nPack bags",
+			want: &note.ImportedNote{Title: "Trip", Content: "Pack bags", Format: note.FormatMarkdown},
+		},
+		{
+			name: "file name title",
+			file: "notes/Reading list.md",
+			src:  "Dune",
+			want: &note.ImportedNote{Title: "Reading list", Content: "Dune", Format: note.FormatMarkdown},
+		},
+		{
+			name: "unknown content format",
+			file: "a.md",
+			src:  "---\ntitle: A\ncontent_format: rtf\n---\ntext",
+			want: &note.ImportedNote{Title: "A", Content: "text", Format: note.FormatMarkdown},
+		},
+		{name: "invalid front matter", file: "a.md", src: "---\ntitle: [\n---\ntext", err: true},
+		{name: "invalid date", file: "a.md", src: "---\ndate: yesterday\n---\ntext", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := parseMarkdown(tt.file, []byte(tt.src), nil)
+			if tt.err {
+				var fe formatError
+				if !errors.As(e.err, &fe) {
+					t.Fatalf("err = %v, want a format error", e.err)
+				}
+				return
+			}
+			if e.err != nil {
+				t.Fatalf("err = %v", e.err)
+			}
+			if !reflect.DeepEqual(e.note, tt.want) {
+				t.Errorf("note = %+v, want %+v", e.note, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownImages(t *testing.T) {
+	files := testArchive(t, map[string]string{
+		"notes/a.md":     "",
+		"notes/cat.png":  "cat",
+		"images/dog.jpg": "dog",
+	})
+	src := "---\ntitle: Pets\nimages:\n  - ../images/dog.jpg\n  - https://example.com/bird.png\n---\n" +
+		"![cat](cat.png \"Cat\") ![gone](missing.png) ![remote](https://example.com/a.png)"
+
+	e := parseMarkdown("notes/a.md", []byte(src), files)
+	if e.err != nil {
+		t.Fatalf("err = %v", e.err)
+	}
+	want := []note.ImportedImage{{Ref: imageRef(0), Data: []byte("cat")}, {Data: []byte("dog")}}
+	if !reflect.DeepEqual(e.note.Images, want) {
+		t.Errorf("images = %+v, want %+v", e.note.Images, want)
+	}
+	wantContent := "![cat](" + imageRef(0) + " \"Cat\") ![gone](missing.png) ![remote](https://example.com/a.png)"
+	if e.note.Content != wantContent {
+		t.Errorf("content = %q, want %q", e.note.Content, wantContent)
+	}
+	if len(e.warnings) != 2 {
+		t.Errorf("warnings = %q, want the missing image and the remote one", e.warnings)
+	}
+}
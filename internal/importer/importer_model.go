@@ -0,0 +1,58 @@
+package importer
+
+import "time"
+
+// Sources an import can come from.
+const (
+	SourceMarkdown = "markdown"
+	SourceEnex     = "enex"
+	SourceKeep     = "keep"
+)
+
+const (
+	ImportPending    = "pending"
+	ImportProcessing = "processing"
+	ImportDone       = "done"
+	ImportFailed     = "failed"
+)
+
+// Item results.
+const (
+	ItemImported = "imported"
+	ItemSkipped  = "skipped"
+	ItemFailed   = "failed"
+)
+
+// ImportJob is an uploaded file of notes from another app, imported in the
+// background. Items records what became of each note found in it.
+type ImportJob struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	UserID      uint         `json:"user_id"`
+	Source      string       `json:"source"`
+	FileName    string       `json:"file_name"`
+	FilePath    string       `json:"-"`
+	Status      string       `json:"status"`
+	Total       int          `json:"total"`
+	Imported    int          `json:"imported"`
+	Skipped     int          `json:"skipped"`
+	Failed      int          `json:"failed"`
+	Error       string       `json:"error,omitempty"`
+	Items       []ImportItem `json:"items,omitempty" gorm:"foreignKey:JobID"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at"`
+
+	// Worker is the replica running the import, which keeps HeartbeatAt
+	// current while it does.
+	Worker      string     `json:"-"`
+	HeartbeatAt *time.Time `json:"-"`
+}
+
+// ImportItem is the result of importing one note of a job.
+type ImportItem struct {
+	ID      uint   `json:"-" gorm:"primaryKey"`
+	JobID   uint   `json:"-"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	NoteID  *uint  `json:"note_id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
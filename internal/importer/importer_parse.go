@@ -0,0 +1,129 @@
+package importer
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"notemind/internal/note"
+)
+
+const (
+	// maxExtractedBytes bounds what is read out of an archive altogether, so
+	// that a small, highly compressed upload cannot exhaust memory or disk.
+	maxExtractedBytes = 200 << 20
+	maxNoteBytes      = 2 << 20
+	maxImageBytes     = 10 << 20
+	maxNoteImages     = 20
+	maxEntries        = 2000
+)
+
+// formatError is a problem with the uploaded file itself, which is
+// reported to the user as is.
+type formatError string
+
+func (e formatError) Error() string { return string(e) }
+
+var (
+	errTooLarge      error = formatError("file is too large")
+	errArchiveBudget error = formatError("archive expands to more than the import limit")
+	errMissingFile   error = formatError("file not found")
+)
+
+// entry is one note found in an upload, or why it cannot be imported.
+type entry struct {
+	name string
+	note *note.ImportedNote
+	// skip is why the note is deliberately left out
+	skip string
+	err  error
+	// warnings are kept with the result of an imported note
+	warnings []string
+}
+
+// visit handles one entry; it returns false to stop the parse.
+type visit func(e entry) bool
+
+// imageExts are the image files that are uploaded along with notes.
+var imageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true,
+}
+
+func isImage(name string) bool {
+	return imageExts[strings.ToLower(path.Ext(name))]
+}
+
+// archive reads files out of a ZIP while keeping to maxExtractedBytes.
+type archive struct {
+	files     map[string]*zip.File
+	names     []string
+	extracted int64
+}
+
+func newArchive(zr *zip.Reader) *archive {
+	a := &archive{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		name := path.Clean(strings.TrimPrefix(f.Name, "/"))
+		if f.FileInfo().IsDir() || ignoredFile(name) {
+			continue
+		}
+		a.files[name] = f
+		a.names = append(a.names, name)
+	}
+	return a
+}
+
+// ignoredFile reports metadata that archivers on macOS add to ZIPs.
+func ignoredFile(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") || path.Base(name) == ".DS_Store"
+}
+
+// read returns the content of the file name, failing when it is larger
+// than limit or would take the archive past its budget.
+func (a *archive) read(name string, limit int64) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, errMissingFile
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := readLimited(rc, limit)
+	if err != nil {
+		return nil, err
+	}
+	a.extracted += int64(len(data))
+	if a.extracted > maxExtractedBytes {
+		return nil, errArchiveBudget
+	}
+	return data, nil
+}
+
+// readLimited reads r to the end, failing with errTooLarge past limit bytes.
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errTooLarge
+	}
+	return data, nil
+}
+
+// imageRef is the placeholder the content refers to the i-th image of a
+// note by until it is uploaded; the suffix keeps image 1 from matching 10.
+func imageRef(i int) string {
+	return fmt.Sprintf("notemind-import-image-%d-ref", i)
+}
+
+// titleFromName is the title of a note that has none of its own.
+func titleFromName(name string) string {
+	base := path.Base(name)
+	return strings.TrimSpace(strings.TrimSuffix(base, path.Ext(base)))
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errJobFinished is returned by UpdateJob for a job that is not pending or
+// processing anymore, failed by recovery while it was still running.
+var errJobFinished = errors.New("import job already finished")
+
+type ImportRepo interface {
+	// CreateJob reports false, creating nothing, when the user already has
+	// a pending or processing job.
+	CreateJob(job *ImportJob) (bool, error)
+	UpdateJob(job *ImportJob) error
+	// GetJob returns the user's job with its items.
+	GetJob(userID, jobID uint) (*ImportJob, error)
+	GetActiveJob(userID uint) (*ImportJob, error)
+	CreateItem(item *ImportItem) error
+	TouchJob(jobID uint, at time.Time) error
+	// FailInterruptedJobs marks the unfinished jobs of worker, which is not
+	// running them anymore, or when worker is "" those whose heartbeat
+	// stopped before staleBefore, so their owners can start a new one.
+	FailInterruptedJobs(worker string, staleBefore time.Time) ([]ImportJob, error)
+}
+
+type importRepo struct {
+	db *gorm.DB
+}
+
+func NewImportRepo(db *gorm.DB) ImportRepo {
+	return &importRepo{db: db}
+}
+
+func (r *importRepo) CreateJob(job *ImportJob) (bool, error) {
+	// idx_import_jobs_user_active allows one unfinished job per user
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	return result.RowsAffected == 1, result.Error
+}
+
+// UpdateJob saves everything but the items and the heartbeat, which only
+// TouchJob moves forward, as long as the job is still pending or processing.
+func (r *importRepo) UpdateJob(job *ImportJob) error {
+	result := r.db.Model(job).
+		Where("status IN ?", []string{ImportPending, ImportProcessing}).
+		Select("*").Omit("ID", "UserID", "CreatedAt", "Items", "HeartbeatAt").
+		Updates(job)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return errJobFinished
+	}
+	return result.Error
+}
+
+func (r *importRepo) GetJob(userID, jobID uint) (*ImportJob, error) {
+	var job ImportJob
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("id = ? AND user_id = ?", jobID, userID).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *importRepo) GetActiveJob(userID uint) (*ImportJob, error) {
+	var job ImportJob
+	err := r.db.Where("user_id = ? AND status IN ?", userID, []string{ImportPending, ImportProcessing}).
+		Order("created_at desc").First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *importRepo) CreateItem(item *ImportItem) error {
+	return r.db.Create(item).Error
+}
+
+func (r *importRepo) TouchJob(jobID uint, at time.Time) error {
+	return r.db.Model(&ImportJob{}).Where("id = ?", jobID).Update("heartbeat_at", at).Error
+}
+
+func (r *importRepo) FailInterruptedJobs(worker string, staleBefore time.Time) ([]ImportJob, error) {
+	interrupted := func() *gorm.DB {
+		query := r.db.Where("status IN ?", []string{ImportPending, ImportProcessing})
+		if worker != "" {
+			return query.Where("worker = ?", worker)
+		}
+		return query.Where("heartbeat_at IS NULL OR heartbeat_at < ?", staleBefore)
+	}
+
+	var jobs []ImportJob
+	err := interrupted().Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(jobs))
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	// only the jobs found, a job started since has its file still in use
+	err = interrupted().Model(&ImportJob{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"status": ImportFailed, "error": "import was interrupted, notes listed in its items were imported"}).Error
+	return jobs, err
+}
@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"notemind/internal/note"
+)
+
+func TestExportArchiveImportsBack(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nnot really")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	}))
+	defer server.Close()
+
+	created := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	updated := time.Date(2024, 5, 3, 18, 0, 0, 0, time.UTC)
+	notes := []note.Note{
+		{
+			ID:            1,
+			Title:         "Trip to Lisbon",
+			Content:       "# Day one\n\nPastéis de *nata*.",
+			ContentFormat: note.FormatMarkdown,
+			Tags:          []note.Tag{{Name: "travel"}, {Name: "food"}},
+			Images:        []note.NoteImage{{ID: 3, ImageURL: server.URL + "/tram.png"}},
+			CreatedAt:     created,
+			UpdatedAt:     updated,
+		},
+		{
+			ID:            2,
+			Title:         "Plain",
+			Content:       "<b>not</b> html",
+			ContentFormat: note.FormatPlain,
+			CreatedAt:     created,
+			UpdatedAt:     created,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := note.WriteExportArchive(context.Background(), &buf, notes, note.ExportMarkdown); err != nil {
+		t.Fatalf("WriteExportArchive: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported := map[string]*note.ImportedNote{}
+	err = parseMarkdownArchive(newArchive(zr), func(e entry) bool {
+		if e.err != nil {
+			t.Errorf("%s: %v", e.name, e.err)
+		} else {
+			imported[e.note.Title] = e.note
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("parseMarkdownArchive: %v", err)
+	}
+
+	for _, n := range notes {
+		got, ok := imported[n.Title]
+		if !ok {
+			t.Errorf("note %q was not imported", n.Title)
+			continue
+		}
+		if got.Content != n.Content || got.Format != n.ContentFormat {
+			t.Errorf("%q: content = %q (%s), want %q (%s)", n.Title, got.Content, got.Format, n.Content, n.ContentFormat)
+		}
+		if strings.Join(got.Tags, ",") != strings.Join(n.TagNames(), ",") {
+			t.Errorf("%q: tags = %q, want %q", n.Title, got.Tags, n.TagNames())
+		}
+		if !got.CreatedAt.Equal(n.CreatedAt) || !got.UpdatedAt.Equal(n.UpdatedAt) {
+			t.Errorf("%q: dates = %v, %v, want %v, %v", n.Title, got.CreatedAt, got.UpdatedAt, n.CreatedAt, n.UpdatedAt)
+		}
+		if len(got.Images) != len(n.Images) {
+			t.Errorf("%q: %d images, want %d", n.Title, len(got.Images), len(n.Images))
+			continue
+		}
+		for _, img := range got.Images {
+			if !bytes.Equal(img.Data, png) {
+				t.Errorf("%q: image = %q, want the downloaded copy", n.Title, img.Data)
+			}
+		}
+	}
+}
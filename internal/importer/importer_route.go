@@ -0,0 +1,13 @@
+package importer
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, importHandler *ImportHandler, authMiddleware gin.HandlerFunc) {
+	v1 := router.Group("/api/v1")
+	v1.POST("/import", authMiddleware, token.RequireScope(token.ScopeNotesWrite), importHandler.StartImport)
+	v1.GET("/import/:id", authMiddleware, token.RequireScope(token.ScopeNotesRead), importHandler.GetImport)
+}
@@ -0,0 +1,369 @@
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"notemind/internal/note"
+	"notemind/internal/scheduler"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultImportDir = "imports"
+	// MaxUploadBytes is the largest file an import accepts.
+	MaxUploadBytes = 50 << 20
+	importTimeout  = 2 * time.Hour
+	noteTimeout    = 2 * time.Minute
+	jobHeartbeat   = 30 * time.Second
+	// a job whose heartbeat is older than this was left by a replica that
+	// stopped
+	jobStaleAfter = 5 * time.Minute
+)
+
+var (
+	ErrImportNotFound   = errors.New("import not found")
+	ErrImportInProgress = errors.New("an import is already in progress")
+	ErrUnknownSource    = errors.New("source must be markdown, enex or keep")
+	ErrUnsupportedFile  = errors.New("upload a .zip of Markdown files or a Keep Takeout, an .enex or a .md file")
+	ErrInvalidArchive   = errors.New("file is not a valid ZIP archive")
+	ErrFileTooLarge     = errors.New("file is larger than 50 MB")
+)
+
+type ImportService interface {
+	// Start stores the upload and imports it in the background. An empty
+	// source is detected from the file.
+	Start(userID uint, file *multipart.FileHeader, source string) (*ImportJob, error)
+	GetJob(userID, jobID uint) (*ImportJob, error)
+	// FailInterrupted marks the jobs this replica was running when it last
+	// stopped and removes their files.
+	FailInterrupted() error
+	// FailAbandoned marks the jobs of replicas that stopped without coming
+	// back. It is meant to run periodically on one replica at a time.
+	FailAbandoned(ctx context.Context) error
+}
+
+type importService struct {
+	repo     ImportRepo
+	notes    note.NoteService
+	instance string
+}
+
+func NewImportService(repo ImportRepo, notes note.NoteService) ImportService {
+	return &importService{repo: repo, notes: notes, instance: scheduler.InstanceID()}
+}
+
+func (s *importService) Start(userID uint, file *multipart.FileHeader, source string) (*ImportJob, error) {
+	if userID == 0 {
+		return nil, errors.New("user ID cannot be zero")
+	}
+	if file.Size > MaxUploadBytes {
+		return nil, ErrFileTooLarge
+	}
+	if active, err := s.repo.GetActiveJob(userID); err == nil {
+		return active, ErrImportInProgress
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	switch source {
+	case "":
+	case SourceMarkdown, SourceEnex, SourceKeep:
+	default:
+		return nil, ErrUnknownSource
+	}
+
+	path, err := s.store(file, ext)
+	if err != nil {
+		return nil, err
+	}
+	if source, err = detectSource(path, ext, source); err != nil {
+		removeFile(path)
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	job := &ImportJob{
+		UserID:      userID,
+		Source:      source,
+		FileName:    filepath.Base(file.Filename),
+		FilePath:    path,
+		Status:      ImportPending,
+		Worker:      s.instance,
+		HeartbeatAt: &now,
+		CreatedAt:   now,
+	}
+	created, err := s.repo.CreateJob(job)
+	if err != nil || !created {
+		removeFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// another upload started a job since GetActiveJob above
+		active, _ := s.repo.GetActiveJob(userID)
+		return active, ErrImportInProgress
+	}
+
+	go s.run(job)
+
+	return job, nil
+}
+
+// store copies the upload to the import directory until it is processed.
+func (s *importService) store(file *multipart.FileHeader, ext string) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(importDir(), 0o700); err != nil {
+		return "", err
+	}
+	dst, err := os.CreateTemp(importDir(), "import-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	n, err := io.Copy(dst, io.LimitReader(src, MaxUploadBytes+1))
+	if err == nil && n > MaxUploadBytes {
+		err = ErrFileTooLarge
+	}
+	if err != nil {
+		removeFile(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// detectSource checks the stored upload fits source, working it out from
+// the file when it is empty.
+func detectSource(path, ext, source string) (string, error) {
+	switch ext {
+	case ".enex":
+		if source == "" || source == SourceEnex {
+			return SourceEnex, nil
+		}
+	case ".md", ".markdown":
+		if source == "" || source == SourceMarkdown {
+			return SourceMarkdown, nil
+		}
+	case ".zip":
+		if source == SourceEnex {
+			break
+		}
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			return "", ErrInvalidArchive
+		}
+		defer zr.Close()
+		if source == "" {
+			source = SourceMarkdown
+			if isKeepArchive(newArchive(&zr.Reader)) {
+				source = SourceKeep
+			}
+		}
+		return source, nil
+	}
+	return "", ErrUnsupportedFile
+}
+
+func (s *importService) GetJob(userID, jobID uint) (*ImportJob, error) {
+	job, err := s.repo.GetJob(userID, jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImportNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *importService) run(job *ImportJob) {
+	defer removeFile(job.FilePath)
+	stop := scheduler.Heartbeat(fmt.Sprintf("import %d", job.ID), jobHeartbeat, func(now time.Time) error {
+		return s.repo.TouchJob(job.ID, now)
+	})
+	defer stop()
+
+	job.Status = ImportProcessing
+	if err := s.repo.UpdateJob(job); errors.Is(err, errJobFinished) {
+		log.Printf("import %d: failed by recovery before it started", job.ID)
+		return
+	} else if err != nil {
+		log.Printf("import %d: failed to update status: %v", job.ID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	err := s.parse(job, func(e entry) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		if job.Total == maxEntries {
+			job.Error = fmt.Sprintf("only the first %d notes were imported", maxEntries)
+			return false
+		}
+		// stop when recovery failed the job, its owner may start another
+		return !errors.Is(s.importEntry(ctx, job, e), errJobFinished)
+	})
+
+	now := time.Now().UTC()
+	job.CompletedAt = &now
+	job.FilePath = ""
+	job.Status = ImportDone
+	switch {
+	case err != nil:
+		log.Printf("import %d failed: %v", job.ID, err)
+		job.Status = ImportFailed
+		job.Error = userError(err)
+	case ctx.Err() != nil:
+		job.Status = ImportFailed
+		job.Error = "import took too long, notes listed in its items were imported"
+	}
+	if err := s.repo.UpdateJob(job); errors.Is(err, errJobFinished) {
+		log.Printf("import %d: failed by recovery while running, result not saved", job.ID)
+	} else if err != nil {
+		log.Printf("import %d: failed to save result: %v", job.ID, err)
+	}
+}
+
+// parse reads the job's file, handing each note found in it to fn.
+func (s *importService) parse(job *ImportJob, fn visit) error {
+	if job.Source == SourceEnex {
+		f, err := os.Open(job.FilePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return parseEnex(f, fn)
+	}
+
+	if strings.EqualFold(filepath.Ext(job.FilePath), ".zip") {
+		zr, err := zip.OpenReader(job.FilePath)
+		if err != nil {
+			return ErrInvalidArchive
+		}
+		defer zr.Close()
+		files := newArchive(&zr.Reader)
+		if job.Source == SourceKeep {
+			return parseKeepArchive(files, fn)
+		}
+		return parseMarkdownArchive(files, fn)
+	}
+
+	f, err := os.Open(job.FilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := readLimited(f, maxNoteBytes)
+	e := entry{name: job.FileName, err: err}
+	if err == nil {
+		e = parseMarkdown(job.FileName, data, nil)
+	}
+	fn(e)
+	return nil
+}
+
+// importEntry creates the note of e and records the result, returning
+// errJobFinished when the job was failed in the meantime.
+func (s *importService) importEntry(ctx context.Context, job *ImportJob, e entry) error {
+	item := &ImportItem{JobID: job.ID, Name: e.name}
+	switch {
+	case e.err != nil:
+		item.Status = ItemFailed
+		item.Message = userError(e.err)
+	case e.skip != "":
+		item.Status = ItemSkipped
+		item.Message = e.skip
+	default:
+		noteCtx, cancel := context.WithTimeout(ctx, noteTimeout)
+		created, err := s.notes.ImportNote(noteCtx, job.UserID, e.note)
+		cancel()
+		if err != nil {
+			log.Printf("import %d: failed to save %q: %v", job.ID, e.name, err)
+			item.Status = ItemFailed
+			item.Message = "note could not be saved"
+			break
+		}
+		item.Status = ItemImported
+		item.NoteID = &created.ID
+		item.Message = strings.Join(e.warnings, "; ")
+	}
+
+	job.Total++
+	switch item.Status {
+	case ItemImported:
+		job.Imported++
+	case ItemSkipped:
+		job.Skipped++
+	default:
+		job.Failed++
+	}
+	if err := s.repo.CreateItem(item); err != nil {
+		log.Printf("import %d: failed to save item %q: %v", job.ID, e.name, err)
+	}
+	err := s.repo.UpdateJob(job)
+	if err != nil && !errors.Is(err, errJobFinished) {
+		log.Printf("import %d: failed to update progress: %v", job.ID, err)
+	}
+	return err
+}
+
+// userError is the message a parse error is reported to the user with;
+// anything not caused by the file itself stays in the logs.
+func userError(err error) string {
+	var fe formatError
+	if errors.As(err, &fe) || errors.Is(err, ErrInvalidArchive) {
+		return err.Error()
+	}
+	return "the file could not be read"
+}
+
+func (s *importService) FailInterrupted() error {
+	return s.failInterrupted(s.instance, time.Time{})
+}
+
+func (s *importService) FailAbandoned(ctx context.Context) error {
+	return s.failInterrupted("", time.Now().Add(-jobStaleAfter))
+}
+
+// failInterrupted fails the jobs FailInterruptedJobs finds and removes
+// their files, which are only here when the job ran on this replica.
+func (s *importService) failInterrupted(worker string, staleBefore time.Time) error {
+	jobs, err := s.repo.FailInterruptedJobs(worker, staleBefore)
+	for _, job := range jobs {
+		removeFile(job.FilePath)
+	}
+	return err
+}
+
+func importDir() string {
+	if dir := os.Getenv("IMPORT_DIR"); dir != "" {
+		return dir
+	}
+	return defaultImportDir
+}
+
+func removeFile(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("failed to remove import file %s: %v", filepath.Base(path), err)
+	}
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+)
+
+// Client is the part of the LLM the rest of the app depends on. It sends
+// the prompt as is, callers are responsible for building it.
+type Client interface {
+	Generate(ctx context.Context, prompt string) (string, error)
+	// GenerateStream calls onChunk with each piece of the answer as it
+	// arrives and returns the whole answer. An error from onChunk, or ctx
+	// being cancelled, stops the generation.
+	GenerateStream(ctx context.Context, prompt string, onChunk func(string) error) (string, error)
+}
+
+var _ Client = (*LLMService)(nil)
+
+const defaultModel = "gemini-2.0-flash"
+
+func (s *LLMService) Generate(ctx context.Context, prompt string) (string, error) {
+	if s == nil || s.client == nil {
+		return "", errors.New("LLM service is not configured")
+	}
+	var resp *genai.GenerateContentResponse
+	err := s.metered(ctx, defaultModel, func() (*genai.UsageMetadata, error) {
+		return s.call(ctx, s.policy.timeout, func(ctx context.Context) (*genai.UsageMetadata, error) {
+			var err error
+			resp, err = s.model.GenerateContent(ctx, genai.Text(prompt))
+			if err != nil {
+				return nil, blocked(err)
+			}
+			return resp.UsageMetadata, nil
+		})
+	})
+	if errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrBlocked) {
+		return "", err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate content: %w", err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil ||
+		len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", errors.New("no valid content generated by AI")
+	}
+
+	return strings.TrimSpace(responseText(resp)), nil
+}
+
+func (s *LLMService) GenerateStream(ctx context.Context, prompt string, onChunk func(string) error) (string, error) {
+	if s == nil || s.client == nil {
+		return "", errors.New("LLM service is not configured")
+	}
+	var out strings.Builder
+	var streamErr error
+	err := s.metered(ctx, defaultModel, func() (*genai.UsageMetadata, error) {
+		return s.call(ctx, s.policy.streamTimeout, func(ctx context.Context) (*genai.UsageMetadata, error) {
+			return s.stream(ctx, prompt, &out, onChunk, &streamErr)
+		})
+	})
+	if err != nil {
+		return out.String(), err
+	}
+	if streamErr != nil {
+		return out.String(), streamErr
+	}
+	if out.Len() == 0 {
+		return "", errors.New("no valid content generated by AI")
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// stream runs one attempt of GenerateStream. It reports an error from
+// onChunk through streamErr, since that is not the model failing.
+func (s *LLMService) stream(ctx context.Context, prompt string, out *strings.Builder, onChunk func(string) error, streamErr *error) (*genai.UsageMetadata, error) {
+	iter := s.model.GenerateContentStream(ctx, genai.Text(prompt))
+	// the usage covers the whole answer and comes with the last chunk
+	var usage *genai.UsageMetadata
+	for {
+		resp, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			return usage, nil
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to generate content: %w", blocked(err))
+			if out.Len() > 0 {
+				// the client already has part of this answer, a retry
+				// would hand it out twice
+				err = &permanentError{err: err}
+			}
+			return usage, err
+		}
+		if resp.UsageMetadata != nil {
+			usage = resp.UsageMetadata
+		}
+		chunk := responseText(resp)
+		if chunk == "" {
+			continue
+		}
+		out.WriteString(chunk)
+		if err := onChunk(chunk); err != nil {
+			// not the model's fault, the stream was cut short
+			*streamErr = err
+			return usage, nil
+		}
+	}
+}
+
+// responseText joins the text parts of the first candidate.
+func responseText(resp *genai.GenerateContentResponse) string {
+	if resp == nil || len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return ""
+	}
+	var out strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if text, ok := part.(genai.Text); ok {
+			out.WriteString(string(text))
+		}
+	}
+	return out.String()
+}
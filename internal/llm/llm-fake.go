@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Fake is a Client for tests and local runs without an API key. It answers
+// with Response, or with the result of Respond when set, and remembers
+// every prompt it was given.
+type Fake struct {
+	Response string
+	Err      error
+	Respond  func(prompt string) (string, error)
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (f *Fake) Generate(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	f.prompts = append(f.prompts, prompt)
+	f.mu.Unlock()
+
+	if f.Respond != nil {
+		return f.Respond(prompt)
+	}
+	return f.Response, f.Err
+}
+
+// GenerateStream hands out the answer word by word.
+func (f *Fake) GenerateStream(ctx context.Context, prompt string, onChunk func(string) error) (string, error) {
+	answer, err := f.Generate(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	for _, chunk := range strings.SplitAfter(answer, " ") {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if err := onChunk(chunk); err != nil {
+			return "", err
+		}
+	}
+	return answer, nil
+}
+
+// Prompts returns the prompts received so far.
+func (f *Fake) Prompts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.prompts...)
+}
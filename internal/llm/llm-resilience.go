@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
+)
+
+const (
+	defaultCallTimeout      = 30 * time.Second
+	defaultStreamTimeout    = 2 * time.Minute
+	defaultMaxAttempts      = 3
+	defaultRetryDelay       = 500 * time.Millisecond
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without calling the model while it has been
+// failing repeatedly.
+var ErrCircuitOpen = errors.New("AI service is temporarily unavailable")
+
+// IsTemporary reports whether a failed call is worth making again later:
+// the user is out of quota, the model is unavailable, or the caller gave up.
+func IsTemporary(err error) bool {
+	return errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrCircuitOpen) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		retryable(err)
+}
+
+// callPolicy bounds every call to the model.
+type callPolicy struct {
+	timeout       time.Duration
+	streamTimeout time.Duration
+	attempts      int
+	baseDelay     time.Duration
+}
+
+// policyFromEnv reads LLM_TIMEOUT, LLM_STREAM_TIMEOUT and LLM_MAX_ATTEMPTS.
+func policyFromEnv() callPolicy {
+	policy := callPolicy{
+		timeout:       defaultCallTimeout,
+		streamTimeout: defaultStreamTimeout,
+		attempts:      defaultMaxAttempts,
+		baseDelay:     defaultRetryDelay,
+	}
+	if d, err := time.ParseDuration(os.Getenv("LLM_TIMEOUT")); err == nil && d > 0 {
+		policy.timeout = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("LLM_STREAM_TIMEOUT")); err == nil && d > 0 {
+		policy.streamTimeout = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("LLM_MAX_ATTEMPTS")); err == nil && n > 0 {
+		policy.attempts = n
+	}
+	return policy
+}
+
+// breakerFromEnv reads LLM_BREAKER_THRESHOLD, where 0 turns the breaker
+// off, and LLM_BREAKER_COOLDOWN.
+func breakerFromEnv() *breaker {
+	b := &breaker{threshold: defaultBreakerThreshold, cooldown: defaultBreakerCooldown}
+	if n, err := strconv.Atoi(os.Getenv("LLM_BREAKER_THRESHOLD")); err == nil && n >= 0 {
+		b.threshold = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("LLM_BREAKER_COOLDOWN")); err == nil && d > 0 {
+		b.cooldown = d
+	}
+	return b
+}
+
+// permanentError marks a failure that must not be retried even though the
+// error itself looks transient, such as a stream that already handed out
+// part of its answer.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// retryable reports whether the model may well succeed if asked again.
+func retryable(err error) bool {
+	var permanent *permanentError
+	if err == nil || errors.As(err, &permanent) {
+		return false
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == 408 || apiErr.Code == 429 || apiErr.Code >= 500
+	}
+	var timeout *timeoutError
+	if errors.As(err, &timeout) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// call runs attempt with a timeout of its own, retrying retryable failures
+// with jittered exponential backoff, and keeps the circuit breaker informed.
+func (s *LLMService) call(ctx context.Context, timeout time.Duration, attempt func(ctx context.Context) (*genai.UsageMetadata, error)) (*genai.UsageMetadata, error) {
+	for n := 1; ; n++ {
+		if err := s.breaker.allow(); err != nil {
+			return nil, err
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		usage, err := attempt(attemptCtx)
+		timedOut := errors.Is(attemptCtx.Err(), context.DeadlineExceeded)
+		cancel()
+
+		switch {
+		case err == nil:
+			s.breaker.success()
+			return usage, nil
+		case ctx.Err() != nil:
+			// the caller gave up, that says nothing about the model
+			s.breaker.abandon()
+			return usage, err
+		case timedOut && !errors.As(err, new(*permanentError)):
+			err = &timeoutError{err: err}
+		}
+
+		if !retryable(err) {
+			// the model answered, just not with what we wanted
+			s.breaker.success()
+			return usage, err
+		}
+		s.breaker.failure()
+		if n >= s.policy.attempts {
+			return usage, err
+		}
+
+		delay := s.policy.baseDelay << (n - 1)
+		delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+		log.Printf("llm: attempt %d failed, retrying in %s: %v", n, delay, err)
+		select {
+		case <-ctx.Done():
+			return usage, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// timeoutError is an attempt that ran out of its own time, which counts as
+// a retryable failure of the model.
+type timeoutError struct {
+	err error
+}
+
+func (e *timeoutError) Error() string { return "model call timed out: " + e.err.Error() }
+func (e *timeoutError) Unwrap() error { return e.err }
+
+// breaker stops calls to the model for a while after threshold consecutive
+// failures, then lets a single call through to probe whether it recovered.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) allow() error {
+	if b == nil || b.threshold == 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.threshold && b.threshold > 0 {
+		log.Printf("llm: model recovered, closing circuit")
+	}
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		log.Printf("llm: %d consecutive failures, opening circuit for %s", b.failures, b.cooldown)
+	}
+}
+
+// abandon ends a call that says nothing about the model's health.
+func (b *breaker) abandon() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
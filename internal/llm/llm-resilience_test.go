@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
+)
+
+// testService has a short retry policy and a breaker that opens after
+// threshold failures.
+func testService(threshold int, cooldown time.Duration) *LLMService {
+	return &LLMService{
+		policy:  callPolicy{timeout: time.Second, attempts: 3, baseDelay: time.Millisecond},
+		breaker: &breaker{threshold: threshold, cooldown: cooldown},
+	}
+}
+
+// generate calls fake through s.call, as the service calls the model.
+func generate(s *LLMService, fake *Fake) (string, error) {
+	var answer string
+	_, err := s.call(context.Background(), s.policy.timeout, func(ctx context.Context) (*genai.UsageMetadata, error) {
+		var err error
+		answer, err = fake.Generate(ctx, "prompt")
+		return nil, err
+	})
+	return answer, err
+}
+
+// failing answers with errs in turn, then with "ok".
+func failing(errs ...error) *Fake {
+	return &Fake{Respond: func(string) (string, error) {
+		if len(errs) == 0 {
+			return "ok", nil
+		}
+		err := errs[0]
+		errs = errs[1:]
+		return "", err
+	}}
+}
+
+func unavailable() error {
+	return &googleapi.Error{Code: 503, Message: "model overloaded"}
+}
+
+func TestCallRetriesRetryableErrors(t *testing.T) {
+	fake := failing(unavailable(), &googleapi.Error{Code: 429})
+	answer, err := generate(testService(0, 0), fake)
+	if err != nil || answer != "ok" {
+		t.Fatalf("generate = %q, %v, want ok", answer, err)
+	}
+	if n := len(fake.Prompts()); n != 3 {
+		t.Errorf("model called %d times, want 3", n)
+	}
+}
+
+func TestCallGivesUpAfterMaxAttempts(t *testing.T) {
+	fake := failing(unavailable(), unavailable(), unavailable(), unavailable())
+	if _, err := generate(testService(0, 0), fake); !retryable(err) {
+		t.Fatalf("err = %v, want the last retryable error", err)
+	}
+	if n := len(fake.Prompts()); n != 3 {
+		t.Errorf("model called %d times, want 3", n)
+	}
+}
+
+func TestCallRetriesTimeouts(t *testing.T) {
+	s := testService(0, 0)
+	s.policy.timeout = 10 * time.Millisecond
+	calls := 0
+	_, err := s.call(context.Background(), s.policy.timeout, func(ctx context.Context) (*genai.UsageMetadata, error) {
+		calls++
+		if calls == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("call = %v after %d attempts, want success after 2", err, calls)
+	}
+}
+
+func TestCallDoesNotRetryPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"bad request", &googleapi.Error{Code: 400}},
+		{"blocked", fmt.Errorf("%w (prompt blocked: BlockReasonSafety)", ErrBlocked)},
+		{"quota", ErrQuotaExceeded},
+		{"permanent", &permanentError{err: unavailable()}},
+		{"other", errors.New("invalid response")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testService(1, time.Minute)
+			fake := failing(tt.err)
+			if _, err := generate(s, fake); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if n := len(fake.Prompts()); n != 1 {
+				t.Errorf("model called %d times, want once", n)
+			}
+			// the model did answer, so the breaker stays closed
+			if err := s.breaker.allow(); err != nil {
+				t.Errorf("breaker.allow = %v, want it closed", err)
+			}
+		})
+	}
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	s := testService(2, time.Minute)
+	s.policy.attempts = 1
+	fake := failing(unavailable(), unavailable())
+
+	for i := 0; i < 2; i++ {
+		if _, err := generate(s, fake); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d rejected before the threshold", i+1)
+		}
+	}
+	if _, err := generate(s, fake); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if n := len(fake.Prompts()); n != 2 {
+		t.Errorf("model called %d times, want the open circuit to reject without calling it", n)
+	}
+}
+
+func TestBreakerHalfOpensAfterCooldown(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	s := testService(1, cooldown)
+	s.policy.attempts = 1
+	if _, err := generate(s, failing(unavailable())); err == nil {
+		t.Fatal("generate succeeded, want the failure that opens the circuit")
+	}
+	if _, err := generate(s, failing()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen during the cooldown", err)
+	}
+
+	time.Sleep(cooldown + 10*time.Millisecond)
+	// a single probe is let through, others are rejected while it runs
+	var concurrent error
+	probe := &Fake{Respond: func(string) (string, error) {
+		_, concurrent = generate(s, failing())
+		return "ok", nil
+	}}
+	if _, err := generate(s, probe); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if !errors.Is(concurrent, ErrCircuitOpen) {
+		t.Errorf("call during the probe = %v, want ErrCircuitOpen", concurrent)
+	}
+	if _, err := generate(s, failing()); err != nil {
+		t.Errorf("after a successful probe: %v, want the circuit closed", err)
+	}
+}
+
+func TestBreakerReopensWhenProbeFails(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	s := testService(1, cooldown)
+	s.policy.attempts = 1
+	generate(s, failing(unavailable()))
+
+	time.Sleep(cooldown + 10*time.Millisecond)
+	if _, err := generate(s, failing(unavailable())); errors.Is(err, ErrCircuitOpen) {
+		t.Fatal("probe rejected after the cooldown")
+	}
+	if _, err := generate(s, failing()); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("err = %v, want the circuit open again", err)
+	}
+}
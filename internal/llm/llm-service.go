@@ -2,11 +2,10 @@ package llm
 
 import (
     "context"
+    "errors"
     "fmt"
     "os"
-    "strings"
     "log"
-    "regexp" // Import the regexp package
 
     "github.com/google/generative-ai-go/genai"
     "google.golang.org/api/option"
@@ -14,6 +13,11 @@ import (
 
 type LLMService struct {
     client *genai.Client
+    // model is shared by all calls, it holds no per-call state
+    model   *genai.GenerativeModel
+    meter   Meter
+    policy  callPolicy
+    breaker *breaker
 }
 
 func NewLLMService() (*LLMService, error) {
@@ -23,62 +27,58 @@ func NewLLMService() (*LLMService, error) {
     if err != nil {
         return nil, fmt.Errorf("failed to create Gemini client: %w", err)
     }
-    return &LLMService{client: client}, nil
+    return &LLMService{
+        client:  client,
+        model:   client.GenerativeModel(defaultModel),
+        policy:  policyFromEnv(),
+        breaker: breakerFromEnv(),
+    }, nil
 }
 
 func (s *LLMService) Close() {
     s.client.Close()
 }
 
-// Function to remove HTML tags from a string
-func removeHTMLTags(input string) string {
-    re := regexp.MustCompile(`<[^>]*>`) // Regular expression to match HTML tags
-    return re.ReplaceAllString(input, "") // Replace HTML tags with an empty string
+func (s *LLMService) GenerateNoteSummary(content string) (string, error) {
+    return s.GenerateStyledSummary(context.Background(), content, DefaultStyle)
 }
 
-// Function to create a structured prompt
-func (s *LLMService) makePrompt(notes string) string {
-    return fmt.Sprintf(`Create a concise and objective summary of the following notes.
-Focus only on the key points, ideas, and information actually present like by seeing this he can remember his note and also read some of his note.
-Do not add structure, headings, or commentary.
-Do not use markdown, bullet points, or emojis.
-Keep it to 1–3 short paragraphs, under 300 words.
-Write in clear, plain English.
-
-Notes:
-%s
-
-Now write the summary:
-`, notes)
+// GenerateStyledSummary summarizes content in one of the registered styles.
+// content is plain text, notes are passed by their text projection.
+func (s *LLMService) GenerateStyledSummary(ctx context.Context, content, style string) (string, error) {
+    return Summarize(ctx, s, content, style)
+}
+// StreamNoteSummary is GenerateStyledSummary handing out the summary as it is generated.
+func (s *LLMService) StreamNoteSummary(ctx context.Context, content, style string, onChunk func(string) error) (string, error) {
+    return StreamSummary(ctx, s, content, style, onChunk)
 }
 
-func (s *LLMService) GenerateNoteSummary(content string) (string, error) {
+// Summarize has c summarize content in one of the registered styles, so
+// that services can summarize with any Client.
+func Summarize(ctx context.Context, c Client, content, style string) (string, error) {
     if len(content) < 1 {
         return "no note today", nil
     }
 
-    // Remove HTML tags from the content
-    cleanContent := removeHTMLTags(content)
-
-    ctx := context.Background()
-    
-    // Create a structured prompt using the makePrompt function
-    prompt := s.makePrompt(cleanContent)
-
-    model := s.client.GenerativeModel("gemini-2.0-flash") // Ensure this model is available
+    // Create a structured prompt for the requested style
+    prompt := summaryPrompt(content, style)
 
-    resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+    summary, err := c.Generate(ctx, prompt)
+    if errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrBlocked) {
+        return "", err
+    }
     if err != nil {
         log.Println("model issue")
         return "", fmt.Errorf("failed to generate summary: %w", err)
     }
-
-    if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil ||
-        len(resp.Candidates[0].Content.Parts) == 0 {
-        log.Println("model issue")
-        return "", fmt.Errorf("no valid content generated by AI")
+    return summary, nil
+}
+// StreamSummary is Summarize handing out the summary as it is generated.
+func StreamSummary(ctx context.Context, c Client, content, style string, onChunk func(string) error) (string, error) {
+    if len(content) < 1 {
+        return "no note today", onChunk("no note today")
     }
 
-    summary := fmt.Sprintf("%v", resp.Candidates[0].Content.Parts[0])
-    return strings.TrimSpace(summary), nil
-}
\ No newline at end of file
+    prompt := summaryPrompt(content, style)
+    return c.GenerateStream(ctx, prompt, onChunk)
+}
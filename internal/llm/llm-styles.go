@@ -0,0 +1,118 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Summary styles a note can be summarized in.
+const (
+	StyleParagraph   = "paragraph"
+	StyleBullets     = "bullets"
+	StyleTLDR        = "tldr"
+	StyleActionItems = "action_items"
+	StyleOutline     = "outline"
+
+	DefaultStyle = StyleParagraph
+)
+
+// SummaryStyle is one entry of the prompt registry. Version must be bumped
+// whenever the style's prompt changes, so that the summaries written with
+// the old one are known to be stale.
+type SummaryStyle struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     int    `json:"version"`
+	// instructions replace the format part of the summary prompt.
+	instructions string
+}
+
+var summaryStyles = map[string]SummaryStyle{
+	StyleParagraph: {
+		Name:        StyleParagraph,
+		Version:     2,
+		Description: "1-3 short paragraphs of plain prose",
+		instructions: `Do not add structure, headings, or commentary.
+Do not use markdown, bullet points, or emojis.
+Keep it to 1–3 short paragraphs, under 300 words.`,
+	},
+	StyleBullets: {
+		Name:        StyleBullets,
+		Version:     2,
+		Description: "A bulleted list of the key points",
+		instructions: `Write 3-8 bullet points, one key point each, starting every line with "- ".
+Do not add headings, commentary, or emojis. Keep it under 200 words.`,
+	},
+	StyleTLDR: {
+		Name:        StyleTLDR,
+		Version:     2,
+		Description: "A single-sentence TL;DR",
+		instructions: `Write exactly one sentence of at most 30 words capturing the main point.
+Do not use markdown or emojis, and do not start with "TL;DR".`,
+	},
+	StyleActionItems: {
+		Name:        StyleActionItems,
+		Version:     2,
+		Description: "Only the action items and decisions",
+		instructions: `List only the action items, todos and decisions, one per line starting with "- ".
+Mention who is responsible and the due date when the notes say so.
+If there are none, write exactly "No action items."`,
+	},
+	StyleOutline: {
+		Name:        StyleOutline,
+		Version:     2,
+		Description: "A study outline with sections and sub-points",
+		instructions: `Write a study outline: short section headings starting with "## ", each followed by 2-4 sub-points starting with "- ".
+Order the sections so they can be studied top to bottom. Keep it under 300 words.`,
+	},
+}
+
+// Styles lists the registered summary styles by name.
+func Styles() []SummaryStyle {
+	list := make([]SummaryStyle, 0, len(summaryStyles))
+	for _, style := range summaryStyles {
+		list = append(list, style)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ValidStyle reports whether name is a registered style.
+func ValidStyle(name string) bool {
+	_, ok := summaryStyles[name]
+	return ok
+}
+
+// PromptVersion is the current version of the style's prompt, 0 for
+// unknown styles.
+func PromptVersion(style string) int {
+	return summaryStyles[style].Version
+}
+
+// PromptVersions maps every style to the current version of its prompt.
+func PromptVersions() map[string]int {
+	versions := make(map[string]int, len(summaryStyles))
+	for name, style := range summaryStyles {
+		versions[name] = style.Version
+	}
+	return versions
+}
+
+// summaryPrompt builds the summary prompt of notes for style, falling back
+// to the default style for unknown names.
+func summaryPrompt(notes, style string) string {
+	s, ok := summaryStyles[style]
+	if !ok {
+		s = summaryStyles[DefaultStyle]
+	}
+	return fmt.Sprintf(`Create a concise and objective summary of the following notes.
+Focus only on the key points, ideas, and information actually present like by seeing this he can remember his note and also read some of his note.
+%s
+Write in clear, plain English.
+%s
+
+%s
+
+Now write the summary:
+`, s.instructions, UntrustedNotice, Untrusted("notes", notes))
+}
@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// Features LLM calls are accounted to.
+const (
+	FeatureSummary = "summary"
+	FeatureDigest  = "digest"
+	FeatureReport  = "report"
+	FeatureReview  = "review"
+	FeatureTasks   = "tasks"
+	FeatureAsk     = "ask"
+)
+
+// ErrQuotaExceeded is returned instead of calling the model once the user
+// has used up their daily budget.
+var ErrQuotaExceeded = errors.New("daily AI quota exceeded, try again tomorrow")
+
+// Call describes one request to the model.
+type Call struct {
+	UserID       uint
+	Feature      string
+	Model        string
+	PromptTokens int
+	OutputTokens int
+	TotalTokens  int
+	Latency      time.Duration
+	Err          error
+}
+
+// Meter accounts for LLM calls. Allow is asked before every call made on
+// behalf of a user and may refuse it with ErrQuotaExceeded; Record is told
+// about every call made, failed ones included.
+type Meter interface {
+	Allow(ctx context.Context, userID uint) error
+	Record(ctx context.Context, call Call)
+}
+
+type callerKey struct{}
+
+type caller struct {
+	userID  uint
+	feature string
+}
+
+// ForUser attributes the LLM calls made with ctx to the user and feature.
+// Calls made without it are recorded but never refused.
+func ForUser(ctx context.Context, userID uint, feature string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller{userID: userID, feature: feature})
+}
+
+func callerFrom(ctx context.Context) caller {
+	c, _ := ctx.Value(callerKey{}).(caller)
+	return c
+}
+
+// SetMeter makes the service account every call to meter.
+func (s *LLMService) SetMeter(meter Meter) {
+	if s == nil {
+		return
+	}
+	s.meter = meter
+}
+
+// metered runs call against the model, enforcing and recording usage.
+// call returns the usage reported by the model, nil if it reported none.
+func (s *LLMService) metered(ctx context.Context, model string, call func() (*genai.UsageMetadata, error)) error {
+	if s.meter == nil {
+		_, err := call()
+		return err
+	}
+
+	who := callerFrom(ctx)
+	if who.userID != 0 {
+		if err := s.meter.Allow(ctx, who.userID); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	usage, err := call()
+	record := Call{
+		UserID:  who.userID,
+		Feature: who.feature,
+		Model:   model,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+	if usage != nil {
+		record.PromptTokens = int(usage.PromptTokenCount)
+		record.OutputTokens = int(usage.CandidatesTokenCount)
+		record.TotalTokens = int(usage.TotalTokenCount)
+	}
+	// the caller may have given up, the call still happened
+	s.meter.Record(context.WithoutCancel(ctx), record)
+	return err
+}
@@ -0,0 +1,128 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+type Address struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+type Message struct {
+	To      Address           `json:"to"`
+	Subject string            `json:"subject"`
+	Text    string            `json:"text"`
+	HTML    string            `json:"html"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Mailer delivers a message through some provider. The sender identity is
+// part of the mailer's configuration, not of the message.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// transientError marks failures worth retrying, such as rate limits, provider
+// outages and network errors.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+func IsTransient(err error) bool {
+	var t *transientError
+	return errors.As(err, &t)
+}
+
+// transientStatus reports whether an HTTP status from a provider is worth retrying.
+func transientStatus(code int) bool {
+	return code == 408 || code == 429 || code >= 500
+}
+
+const (
+	defaultFromEmail   = "notereviveapp@gmail.com"
+	defaultFromName    = "NoteRevive"
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = time.Second
+)
+
+// ErrNoProvider is returned outside development when MAIL_PROVIDER is not
+// set and there are no Mailjet keys, rather than keeping mail in the outbox
+// where nobody receives it.
+var ErrNoProvider = errors.New("MAIL_PROVIDER is not set, set it to mailjet, mailersend, smtp or outbox")
+
+// NewFromEnv picks the provider from MAIL_PROVIDER (mailjet, mailersend,
+// smtp or outbox) and wraps it with retries. Without MAIL_PROVIDER it uses
+// Mailjet when its keys are set, and otherwise the local outbox, but only
+// when APP_ENV is development.
+func NewFromEnv() (Mailer, error) {
+	from := Address{Email: os.Getenv("MAIL_FROM_EMAIL"), Name: os.Getenv("MAIL_FROM_NAME")}
+	if from.Email == "" {
+		from.Email = defaultFromEmail
+	}
+	if from.Name == "" {
+		from.Name = defaultFromName
+	}
+
+	provider := os.Getenv("MAIL_PROVIDER")
+	if provider == "" {
+		switch {
+		case os.Getenv("MJ_APIKEY_PUBLIC") != "" && os.Getenv("MJ_APIKEY_PRIVATE") != "":
+			provider = "mailjet"
+		case os.Getenv("APP_ENV") == "development":
+			provider = "outbox"
+			log.Printf("WARNING: MAIL_PROVIDER is not set, mail is only written to the local outbox and never sent")
+		default:
+			return nil, ErrNoProvider
+		}
+	}
+
+	var m Mailer
+	var err error
+	switch provider {
+	case "mailjet":
+		m, err = NewMailjet(os.Getenv("MJ_APIKEY_PUBLIC"), os.Getenv("MJ_APIKEY_PRIVATE"), from)
+	case "mailersend":
+		m, err = NewMailerSend(os.Getenv("MAILERSEND_API_KEY"), from)
+	case "smtp":
+		m, err = NewSMTP(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}, from)
+	case "outbox":
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "outbox"
+		}
+		m, err = NewOutbox(dir, from)
+	default:
+		return nil, fmt.Errorf("unknown MAIL_PROVIDER %q", provider)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s mailer: %w", provider, err)
+	}
+
+	attempts := defaultMaxAttempts
+	if v, err := strconv.Atoi(os.Getenv("MAIL_MAX_ATTEMPTS")); err == nil && v > 0 {
+		attempts = v
+	}
+	return WithRetry(m, attempts, defaultRetryDelay), nil
+}
@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewFromEnvWithoutProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr error
+	}{
+		{"mailjet keys", map[string]string{"MJ_APIKEY_PUBLIC": "public", "MJ_APIKEY_PRIVATE": "private"}, nil},
+		{"development", map[string]string{"APP_ENV": "development"}, nil},
+		{"production", map[string]string{"APP_ENV": "production"}, ErrNoProvider},
+		{"no environment", nil, ErrNoProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"MAIL_PROVIDER", "MJ_APIKEY_PUBLIC", "MJ_APIKEY_PRIVATE", "APP_ENV"} {
+				t.Setenv(key, tt.env[key])
+			}
+			t.Setenv("MAIL_OUTBOX_DIR", t.TempDir())
+
+			m, err := NewFromEnv()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewFromEnv = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && m == nil {
+				t.Error("NewFromEnv returned no mailer")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/mailersend/mailersend-go"
+)
+
+type mailerSendMailer struct {
+	client *mailersend.Mailersend
+	from   Address
+}
+
+func NewMailerSend(apiKey string, from Address) (Mailer, error) {
+	if apiKey == "" {
+		return nil, errors.New("MAILERSEND_API_KEY must be set in environment")
+	}
+	return &mailerSendMailer{client: mailersend.NewMailersend(apiKey), from: from}, nil
+}
+
+func (m *mailerSendMailer) Send(ctx context.Context, msg Message) error {
+	message := m.client.Email.NewMessage()
+	message.SetFrom(mailersend.From{Email: m.from.Email, Name: m.from.Name})
+	message.SetRecipients([]mailersend.Recipient{{Email: msg.To.Email, Name: msg.To.Name}})
+	message.SetSubject(msg.Subject)
+	message.SetText(msg.Text)
+	message.SetHTML(msg.HTML)
+
+	var headers []mailersend.Header
+	for k, v := range msg.Headers {
+		// MailerSend has a dedicated field for this header
+		if k == "List-Unsubscribe" {
+			message.SetListUnsubscribe(v)
+			continue
+		}
+		headers = append(headers, mailersend.Header{Name: k, Value: v})
+	}
+	if len(headers) > 0 {
+		message.SetHeaders(headers)
+	}
+
+	_, err := m.client.Email.Send(ctx, message)
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *mailersend.ErrorResponse
+	if errors.As(err, &apiErr) && apiErr.Response != nil && transientStatus(apiErr.Response.StatusCode) {
+		return Transient(err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Transient(err)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	mailjet "github.com/mailjet/mailjet-apiv3-go"
+)
+
+type mailjetMailer struct {
+	client *mailjet.Client
+	from   Address
+}
+
+func NewMailjet(publicKey, privateKey string, from Address) (Mailer, error) {
+	if publicKey == "" || privateKey == "" {
+		return nil, errors.New("MJ_APIKEY_PUBLIC and MJ_APIKEY_PRIVATE must be set in environment")
+	}
+	return &mailjetMailer{client: mailjet.NewMailjetClient(publicKey, privateKey), from: from}, nil
+}
+
+func (m *mailjetMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	info := mailjet.InfoMessagesV31{
+		From: &mailjet.RecipientV31{Email: m.from.Email, Name: m.from.Name},
+		To: &mailjet.RecipientsV31{
+			mailjet.RecipientV31{Email: msg.To.Email, Name: msg.To.Name},
+		},
+		Subject:  msg.Subject,
+		TextPart: msg.Text,
+		HTMLPart: msg.HTML,
+	}
+	if len(msg.Headers) > 0 {
+		info.Headers = make(map[string]interface{}, len(msg.Headers))
+		for k, v := range msg.Headers {
+			info.Headers[k] = v
+		}
+	}
+
+	_, err := m.client.SendMailV31(&mailjet.MessagesV31{Info: []mailjet.InfoMessagesV31{info}})
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *mailjet.ErrorInfoV31
+	if errors.As(err, &apiErr) && transientStatus(apiErr.StatusCode) {
+		return Transient(err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Transient(err)
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// OutboxEntry is one message written by the outbox mailer.
+type OutboxEntry struct {
+	From   Address   `json:"from"`
+	SentAt time.Time `json:"sent_at"`
+	Message
+}
+
+type outboxMailer struct {
+	dir  string
+	from Address
+	seq  atomic.Uint64
+}
+
+// NewOutbox returns a mailer that writes every message as a JSON file into
+// dir instead of delivering it, for local development.
+func NewOutbox(dir string, from Address) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &outboxMailer{dir: dir, from: from}, nil
+}
+
+func (m *outboxMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	entry := OutboxEntry{From: m.from, SentAt: time.Now().UTC(), Message: msg}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%04d.json", entry.SentAt.Format("20060102T150405.000000000"), m.seq.Add(1))
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o644)
+}
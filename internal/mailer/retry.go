@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+)
+
+type retryingMailer struct {
+	next      Mailer
+	attempts  int
+	baseDelay time.Duration
+}
+
+// WithRetry retries transient failures with exponential backoff and jitter.
+// Permanent failures such as a rejected address are returned right away.
+func WithRetry(next Mailer, attempts int, baseDelay time.Duration) Mailer {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &retryingMailer{next: next, attempts: attempts, baseDelay: baseDelay}
+}
+
+func (m *retryingMailer) Send(ctx context.Context, msg Message) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = m.next.Send(ctx, msg)
+		if err == nil || !IsTransient(err) || attempt >= m.attempts {
+			return err
+		}
+
+		delay := m.baseDelay << (attempt - 1)
+		delay += time.Duration(rand.Int63n(int64(delay)/2 + 1))
+		log.Printf("mailer: attempt %d to %s failed, retrying in %s: %v", attempt, msg.To.Email, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+type smtpMailer struct {
+	cfg  SMTPConfig
+	from Address
+}
+
+func NewSMTP(cfg SMTPConfig, from Address) (Mailer, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("SMTP_HOST must be set in environment")
+	}
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return &smtpMailer{cfg: cfg, from: from}, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	body, err := m.build(msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+	err = smtp.SendMail(net.JoinHostPort(m.cfg.Host, m.cfg.Port), auth, m.from.Email, []string{msg.To.Email}, body)
+	if err == nil {
+		return nil
+	}
+
+	// 4xx replies are temporary by definition, 5xx are permanent
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		if protoErr.Code >= 400 && protoErr.Code < 500 {
+			return Transient(err)
+		}
+		return err
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return Transient(err)
+	}
+	return err
+}
+
+// build renders msg as a multipart/alternative MIME message.
+func (m *smtpMailer) build(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	headers := map[string]string{
+		"From":         formatAddress(m.from),
+		"To":           formatAddress(msg.To),
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+		"Content-Type": "multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var head bytes.Buffer
+	for _, k := range names {
+		fmt.Fprintf(&head, "%s: %s\r\n", k, headers[k])
+	}
+	head.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	}
+	for _, p := range parts {
+		if p.body == "" {
+			continue
+		}
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+func formatAddress(a Address) string {
+	if a.Name == "" {
+		return a.Email
+	}
+	return mime.QEncoding.Encode("utf-8", a.Name) + " <" + a.Email + ">"
+}
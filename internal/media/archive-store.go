@@ -0,0 +1,89 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// ArchiveStore keeps private files, such as data exports, where every
+// replica can reach them. They are only ever handed out through short
+// lived signed URLs.
+type ArchiveStore interface {
+	// Upload stores file under name in folder and returns its ID.
+	Upload(ctx context.Context, file io.Reader, folder, name string) (string, error)
+	// URL signs a link that downloads the archive as an attachment for the
+	// next hour.
+	URL(id string) (string, error)
+	// Delete removes an archive. Archives that are already gone count as
+	// deleted.
+	Delete(ctx context.Context, id string) error
+}
+
+type cloudinaryArchives struct {
+	cld *cloudinary.Cloudinary
+}
+
+// NewCloudinaryArchiveStore keeps archives as private raw assets of the
+// Cloudinary account note images are uploaded to.
+func NewCloudinaryArchiveStore() (ArchiveStore, error) {
+	cld, err := newCloudinary()
+	if err != nil {
+		return nil, err
+	}
+	return &cloudinaryArchives{cld: cld}, nil
+}
+
+func (s *cloudinaryArchives) Upload(ctx context.Context, file io.Reader, folder, name string) (string, error) {
+	overwrite := false
+	result, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
+		ResourceType: api.File,
+		Type:         api.Private,
+		Folder:       folder,
+		PublicID:     name,
+		Overwrite:    &overwrite,
+	})
+	if err != nil {
+		return "", errors.New("upload failed: " + err.Error())
+	}
+	if result.Error.Message != "" {
+		return "", errors.New("upload failed: " + result.Error.Message)
+	}
+	return result.PublicID, nil
+}
+
+// URL leaves the expiry to Cloudinary, whose private download links last an
+// hour: the SDK would not send ExpiresAt as the timestamp the API expects.
+func (s *cloudinaryArchives) URL(id string) (string, error) {
+	return s.cld.Upload.PrivateDownloadURL(uploader.PrivateDownloadURLParams{
+		PublicID:     id,
+		DeliveryType: api.Private,
+		ResourceType: api.File,
+		Attachment:   "true",
+	})
+}
+
+func (s *cloudinaryArchives) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return errors.New("archive ID is required")
+	}
+	result, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     id,
+		Type:         api.Private,
+		ResourceType: api.File,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return errors.New("delete failed: " + result.Error.Message)
+	}
+	if result.Result != "ok" && result.Result != "not found" {
+		return errors.New("delete failed: " + result.Result)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+// Store keeps note images somewhere reachable by URL.
+type Store interface {
+	Upload(ctx context.Context, file io.Reader, folder string) (*UploadResult, error)
+	Delete(ctx context.Context, publicID string) error
+}
+
+type UploadResult struct {
+	URL      string
+	PublicID string
+}
+
+type cloudinaryStore struct {
+	cld *cloudinary.Cloudinary
+}
+
+func NewCloudinaryStore() (Store, error) {
+	cld, err := newCloudinary()
+	if err != nil {
+		return nil, err
+	}
+	return &cloudinaryStore{cld: cld}, nil
+}
+
+func newCloudinary() (*cloudinary.Cloudinary, error) {
+	cld, err := cloudinary.NewFromParams(
+		os.Getenv("CLOUDINARY_CLOUD_NAME"),
+		os.Getenv("CLOUDINARY_API_KEY"),
+		os.Getenv("CLOUDINARY_API_SECRET"),
+	)
+	if err != nil {
+		return nil, errors.New("cloudinary config failed")
+	}
+	return cld, nil
+}
+
+func (s *cloudinaryStore) Upload(ctx context.Context, file io.Reader, folder string) (*UploadResult, error) {
+	result, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
+		ResourceType: "image",
+		Folder:       folder,
+	})
+	if err != nil {
+		return nil, errors.New("upload failed: " + err.Error())
+	}
+	if result.Error.Message != "" {
+		return nil, errors.New("upload failed: " + result.Error.Message)
+	}
+	return &UploadResult{URL: result.SecureURL, PublicID: result.PublicID}, nil
+}
+
+// Delete removes an asset. Assets that are already gone count as deleted.
+func (s *cloudinaryStore) Delete(ctx context.Context, publicID string) error {
+	if publicID == "" {
+		return errors.New("public ID is required")
+	}
+	result, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     publicID,
+		ResourceType: "image",
+	})
+	if err != nil {
+		return err
+	}
+	if result.Error.Message != "" {
+		return errors.New("delete failed: " + result.Error.Message)
+	}
+	if result.Result != "ok" && result.Result != "not found" {
+		return errors.New("delete failed: " + result.Result)
+	}
+	return nil
+}
+
+// PublicIDFromURL recovers the public ID of images uploaded before it was
+// stored, e.g. .../image/upload/v1712/notes/abc.jpg gives notes/abc.
+func PublicIDFromURL(imageURL string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+	_, rest, found := strings.Cut(u.Path, "/upload/")
+	if !found {
+		return ""
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) > 1 && strings.HasPrefix(parts[0], "v") && strings.Trim(parts[0][1:], "0123456789") == "" {
+		parts = parts[1:]
+	}
+	id := strings.Join(parts, "/")
+	return strings.TrimSuffix(id, path.Ext(id))
+}
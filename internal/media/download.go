@@ -0,0 +1,48 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+var downloadClient = &http.Client{Timeout: 30 * time.Second}
+
+// Download fetches a stored image, for copying it into an archive. The
+// caller closes the returned body.
+func Download(ctx context.Context, imageURL string) (io.ReadCloser, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("download failed: %w", err)
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("download failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// FileExt is the extension to save a downloaded image with, taken from its
+// URL or else from its content type.
+func FileExt(imageURL, contentType string) string {
+	ext := ""
+	if u, err := url.Parse(imageURL); err == nil {
+		ext = path.Ext(u.Path)
+	}
+	if ext == "" || len(ext) > 5 {
+		ext = ""
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return ext
+}
@@ -0,0 +1,67 @@
+package note
+
+import (
+	"html/template"
+	"strings"
+
+	"notemind/internal/render"
+)
+
+// Content formats. HTML comes from rich-text editors and is sanitized
+// before it is stored; markdown is stored as written and only rendered,
+// sanitized, when it is displayed.
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// ValidFormat reports whether format is a known content format.
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatPlain, FormatMarkdown, FormatHTML:
+		return true
+	}
+	return false
+}
+
+// sanitizeContent is what is stored of content written in format.
+func sanitizeContent(content, format string) string {
+	if format == FormatHTML {
+		return render.SanitizeHTML(content)
+	}
+	return content
+}
+
+// plainText is the text of content without markup, which notes are searched
+// and summarized by.
+func plainText(content, format string) string {
+	switch format {
+	case FormatHTML:
+		return render.HTMLText(content)
+	case FormatMarkdown:
+		return render.MarkdownText(content)
+	default:
+		return strings.TrimSpace(content)
+	}
+}
+
+// HTML renders the note's content as sanitized HTML for read-only views.
+func (n *Note) HTML() (template.HTML, error) {
+	switch n.ContentFormat {
+	case FormatHTML:
+		// sanitized again in case the allowlist got stricter since it was stored
+		return template.HTML(render.SanitizeHTML(n.Content)), nil
+	case FormatMarkdown:
+		return render.Markdown(n.Content)
+	default:
+		return render.PlainHTML(n.Content), nil
+	}
+}
+
+// setContent stores content written in format along with its plain text.
+func (n *Note) setContent(content, format string) {
+	n.ContentFormat = format
+	n.Content = sanitizeContent(content, format)
+	n.ContentText = plainText(n.Content, format)
+}
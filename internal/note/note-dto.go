@@ -1,13 +1,33 @@
 package note
 
+import "time"
+
 type CreateNoteDTO struct {
-	Title   string `form:"title"`
-	Content string `form:"content"`
+	Title         string `form:"title"`
+	Content       string `form:"content"`
+	ContentFormat string `form:"content_format"`
+	SummaryStyle  string `form:"summary_style"`
 }
 
 // Add this to your existing DTO file:
 
 type UpdateNoteDTO struct {
-	Title   string `form:"title"`
-	Content string `form:"content"`
+	Title         string `form:"title"`
+	Content       string `form:"content"`
+	ContentFormat string `form:"content_format"`
+	SummaryStyle  string `form:"summary_style"`
+}
+
+type SetTagsDTO struct {
+	Tags []string `json:"tags"`
+}
+
+type ShareNoteDTO struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type CreatePublicLinkDTO struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	Password  string     `json:"password"`
 }
@@ -0,0 +1,222 @@
+package note
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"time"
+
+	"notemind/internal/media"
+	"notemind/internal/render"
+)
+
+// Export formats.
+const (
+	ExportMarkdown = "md"
+	ExportHTML     = "html"
+	ExportJSON     = "json"
+)
+
+const (
+	// maxExportNotes caps a tag export, the account export covers more.
+	maxExportNotes      = 1000
+	maxExportImageBytes = 25 << 20
+)
+
+var (
+	ErrUnknownExportFormat = errors.New("format must be md, html or json")
+	ErrTagNotFound         = errors.New("tag not found")
+	ErrExportTooLarge      = fmt.Errorf("only tags with up to %d notes can be exported, export your account instead", maxExportNotes)
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var noteHTML = template.Must(template.ParseFS(templateFS, "templates/note.html.tmpl"))
+
+// ExportedNote is a note as exported to JSON.
+type ExportedNote struct {
+	ID            uint            `json:"id"`
+	Title         string          `json:"title"`
+	Content       string          `json:"content"`
+	ContentFormat string          `json:"content_format"`
+	Summary       string          `json:"summary"`
+	SummaryStyle  string          `json:"summary_style"`
+	Tags          []string        `json:"tags"`
+	Images        []ExportedImage `json:"images"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type ExportedImage struct {
+	URL  string `json:"url"`
+	File string `json:"file,omitempty"`
+}
+
+// noteDocument is what the HTML export template renders.
+type noteDocument struct {
+	Title     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Tags      []string
+	Content   template.HTML
+	Summary   template.HTML
+	Images    []string
+}
+
+// ValidExportFormat reports whether format is one of the export formats.
+func ValidExportFormat(format string) bool {
+	switch format {
+	case ExportMarkdown, ExportHTML, ExportJSON:
+		return true
+	}
+	return false
+}
+
+// ExportContentType is the media type of a note exported in format.
+func ExportContentType(format string) string {
+	switch format {
+	case ExportHTML:
+		return "text/html; charset=utf-8"
+	case ExportJSON:
+		return "application/json"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// Export renders the note in format. files maps image IDs to the paths of
+// their copies in an archive; images without one are referenced by URL.
+func (n *Note) Export(format string, files map[uint]string) ([]byte, error) {
+	switch format {
+	case ExportMarkdown:
+		return []byte(n.markdown(files)), nil
+	case ExportHTML:
+		return n.exportHTML(files)
+	case ExportJSON:
+		return json.MarshalIndent(n.exportJSON(files), "", "  ")
+	}
+	return nil, ErrUnknownExportFormat
+}
+
+// exportHTML renders a standalone page that prints well, for saving as PDF.
+func (n *Note) exportHTML(files map[uint]string) ([]byte, error) {
+	content, err := n.HTML()
+	if err != nil {
+		return nil, err
+	}
+	summary, err := render.Markdown(n.Summary)
+	if err != nil {
+		return nil, err
+	}
+	doc := noteDocument{
+		Title:     n.Title,
+		CreatedAt: n.CreatedAt.UTC(),
+		UpdatedAt: n.UpdatedAt.UTC(),
+		Tags:      n.TagNames(),
+		Content:   content,
+		Summary:   summary,
+	}
+	if doc.Title == "" {
+		doc.Title = "Untitled note"
+	}
+	for _, img := range n.Images {
+		doc.Images = append(doc.Images, imageSrc(img, files))
+	}
+
+	var buf bytes.Buffer
+	if err := noteHTML.Execute(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (n *Note) exportJSON(files map[uint]string) ExportedNote {
+	exported := ExportedNote{
+		ID:            n.ID,
+		Title:         n.Title,
+		Content:       n.Content,
+		ContentFormat: n.ContentFormat,
+		Summary:       n.Summary,
+		SummaryStyle:  n.SummaryStyle,
+		Tags:          n.TagNames(),
+		Images:        make([]ExportedImage, 0, len(n.Images)),
+		CreatedAt:     n.CreatedAt,
+		UpdatedAt:     n.UpdatedAt,
+	}
+	for _, img := range n.Images {
+		exported.Images = append(exported.Images, ExportedImage{URL: img.ImageURL, File: files[img.ID]})
+	}
+	return exported
+}
+
+// WriteExportArchive writes a ZIP of notes in format to w: one file per
+// note under notes/ and copies of their images under images/, which the
+// notes refer to. Images that cannot be downloaded stay referenced by URL.
+func WriteExportArchive(ctx context.Context, w io.Writer, notes []Note, format string) error {
+	if !ValidExportFormat(format) {
+		return ErrUnknownExportFormat
+	}
+	zw := zip.NewWriter(w)
+	for i := range notes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := &notes[i]
+		files := make(map[uint]string, len(n.Images))
+		for _, img := range n.Images {
+			name, err := copyExportImage(ctx, zw, n.ID, img)
+			if err != nil {
+				log.Printf("note: export of image %d failed: %v", img.ID, err)
+				continue
+			}
+			// notes are one directory down from the images
+			files[img.ID] = "../" + name
+		}
+
+		data, err := n.Export(format, files)
+		if err != nil {
+			return fmt.Errorf("export note %d: %w", n.ID, err)
+		}
+		f, err := zw.Create("notes/" + n.FileName() + "." + format)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func copyExportImage(ctx context.Context, zw *zip.Writer, noteID uint, img NoteImage) (string, error) {
+	body, contentType, err := media.Download(ctx, img.ImageURL)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	// read first so that a failed download leaves no half-written entry
+	data, err := io.ReadAll(io.LimitReader(body, maxExportImageBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxExportImageBytes {
+		return "", fmt.Errorf("image larger than %d bytes", maxExportImageBytes)
+	}
+
+	name := fmt.Sprintf("images/%d/%d%s", noteID, img.ID, media.FileExt(img.ImageURL, contentType))
+	f, err := zw.Create(name)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	return name, err
+}
@@ -1,13 +1,28 @@
 package note
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"notemind/internal/llm"
+	"notemind/internal/sse"
+	"notemind/internal/token"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	defaultResummarizeLimit = 100
+	maxResummarizeLimit     = 1000
+)
+
 type NoteHandler struct {
 	noteService NoteService
 }
@@ -17,21 +32,13 @@ func NewNoteHandler(noteService NoteService) *NoteHandler {
 }
 
 func (h *NoteHandler) CreateNote(ctx *gin.Context) {
-	// Get user_id from middleware context with proper type assertion
-	userIDInterface, exists := ctx.Get("user_id")
-	if !exists {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
 		log.Println("user ID does not exist")
 		ctx.JSON(401, gin.H{"error": "User not authenticated"})
 		return
 	}
-	
-	// Convert interface{} to uint
-	userID, ok := userIDInterface.(uint)
-	if !ok {
-		log.Println("Invalid user ID type")
-		ctx.JSON(401, gin.H{"error": "Invalid user ID type"})
-		return
-	}
+	userID := user.UserID
 
 	var req CreateNoteDTO
 	if err := ctx.ShouldBind(&req); err != nil {
@@ -49,7 +56,11 @@ func (h *NoteHandler) CreateNote(ctx *gin.Context) {
 	audioFile, err := ctx.FormFile("audio")
 	if err == nil {
 		// Create voice note - declare note variable here
-		note, err := h.noteService.CreateVoiceNote(userID, audioFile, req.Title, imageFile)
+		note, err := h.noteService.CreateVoiceNote(ctx.Request.Context(), userID, audioFile, req.Title, req.SummaryStyle, imageFile)
+		if errors.Is(err, ErrUnknownStyle) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -62,7 +73,11 @@ func (h *NoteHandler) CreateNote(ctx *gin.Context) {
 	}
 
 	// Create regular note - declare note variable here
-	note, err := h.noteService.CreateNote(userID, req.Title, req.Content, imageFile)
+	note, err := h.noteService.CreateNote(ctx.Request.Context(), userID, req.Title, req.Content, req.ContentFormat, req.SummaryStyle, imageFile)
+	if errors.Is(err, ErrUnknownStyle) || errors.Is(err, ErrUnknownFormat) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -77,18 +92,12 @@ func (h *NoteHandler) CreateNote(ctx *gin.Context) {
 
 func (h *NoteHandler) UpdateNote(ctx *gin.Context) {
 
-	userIDInterface, exists := ctx.Get("user_id")
-	if !exists {
-		ctx.JSON(401, gin.H{"error": "User not authenticated"})
-		return
-	}
-	
-	// Convert interface{} to uint
-	userID, ok := userIDInterface.(uint)
+	user, ok := token.CurrentUser(ctx)
 	if !ok {
-		ctx.JSON(401, gin.H{"error": "Invalid user ID type"})
+		ctx.JSON(401, gin.H{"error": "User not authenticated"})
 		return
 	}
+	userID := user.UserID
 
 	noteIDStr := ctx.Param("id")
 	noteID, err := strconv.ParseUint(noteIDStr, 10, 32)
@@ -117,16 +126,22 @@ func (h *NoteHandler) UpdateNote(ctx *gin.Context) {
 	// Replace with actual user from JWT middleware
 
 	// STEP 5: Update note
-	err = h.noteService.UpdateNote(uint(noteID), userID, req.Title, req.Content, imageFile)
+	err = h.noteService.UpdateNote(ctx.Request.Context(), uint(noteID), userID, req.Title, req.Content, req.ContentFormat, req.SummaryStyle, imageFile)
 	if err != nil {
 		// Handle different error types
-		if err.Error() == "note not found" {
+		if errors.Is(err, ErrUnknownStyle) || errors.Is(err, ErrUnknownFormat) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, ErrNoteNotFound) {
 			ctx.JSON(http.StatusNotFound, gin.H{
 				"error": "Note not found",
 			})
 			return
 		}
-		if err.Error() == "unauthorized: you don't own this note" {
+		if errors.Is(err, ErrForbidden) {
 			ctx.JSON(http.StatusForbidden, gin.H{
 				"error": "Unauthorized access",
 			})
@@ -147,6 +162,11 @@ func (h *NoteHandler) UpdateNote(ctx *gin.Context) {
 }
 
 func (h *NoteHandler) GetOneNote(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
 	id := ctx.Param("id")
 	idStr, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
@@ -161,8 +181,14 @@ func (h *NoteHandler) GetOneNote(ctx *gin.Context) {
 		})
 		return
 	}
-	res, err := h.noteService.GetOneNote(uint(idStr))
+	res, err := h.noteService.GetOneNote(uint(idStr), user.UserID)
 
+	if errors.Is(err, ErrNoteNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Note not found",
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": "server error",
@@ -175,3 +201,568 @@ func (h *NoteHandler) GetOneNote(ctx *gin.Context) {
 	})
 
 }
+
+// RenderNote returns the note's content as sanitized HTML, for views that
+// display it rather than edit it.
+func (h *NoteHandler) RenderNote(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	note, html, err := h.noteService.RenderNote(uint(noteID), user.UserID)
+	switch {
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render note"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"note_id":        note.ID,
+		"title":          note.Title,
+		"content_format": note.ContentFormat,
+		"html":           html,
+	})
+}
+
+// SetTags replaces the note's tags, an empty list removing them all.
+func (h *NoteHandler) SetTags(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+	var req SetTagsDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tags, err := h.noteService.SetTags(uint(noteID), user.UserID, req.Tags)
+	switch {
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tags"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+func (h *NoteHandler) ListTags(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	tags, err := h.noteService.ListTags(user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// ExportNote downloads the note as ?format=md (the default), html or json.
+func (h *NoteHandler) ExportNote(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	format := ctx.DefaultQuery("format", ExportMarkdown)
+	note, data, err := h.noteService.ExportNote(uint(noteID), user.UserID, format)
+	switch {
+	case errors.Is(err, ErrUnknownExportFormat):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export note"})
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, note.FileName(), format))
+	ctx.Data(http.StatusOK, ExportContentType(format), data)
+}
+
+// ExportTag downloads a ZIP of the notes under a tag, in ?format=md (the
+// default), html or json, with their images.
+func (h *NoteHandler) ExportTag(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	tagID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || tagID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag ID"})
+		return
+	}
+	format := ctx.DefaultQuery("format", ExportMarkdown)
+	if !ValidExportFormat(format) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": ErrUnknownExportFormat.Error()})
+		return
+	}
+
+	tag, notes, err := h.noteService.NotesByTag(user.UserID, uint(tagID))
+	switch {
+	case errors.Is(err, ErrTagNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrExportTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export notes"})
+		return
+	}
+
+	name := strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(tag.Name), "-"), "-")
+	if name == "" {
+		name = strconv.FormatUint(uint64(tag.ID), 10)
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="notemind-%s.zip"`, name))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Status(http.StatusOK)
+	// the archive is streamed, a failure halfway can only cut it short
+	if err := WriteExportArchive(ctx.Request.Context(), ctx.Writer, notes, format); err != nil {
+		log.Printf("note: export of tag %d failed: %v", tag.ID, err)
+	}
+}
+
+// Summarize rewrites the note's summary in the ?style= given, or in the
+// note's current style when none is.
+func (h *NoteHandler) Summarize(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	note, err := h.noteService.Summarize(ctx.Request.Context(), uint(noteID), user.UserID, ctx.Query("style"))
+	switch {
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrUnknownStyle):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, llm.ErrQuotaExceeded):
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, llm.ErrCircuitOpen):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, llm.ErrBlocked):
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": llm.ErrBlocked.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate summary"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"note": note})
+}
+
+// ResummarizeStale lets an admin regenerate the summaries written with an
+// outdated prompt, at most ?limit= of them per call.
+func (h *NoteHandler) ResummarizeStale(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultResummarizeLimit)))
+	if err != nil || limit < 1 || limit > maxResummarizeLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+		return
+	}
+
+	// the admin closing the tab must not abort the run halfway through
+	report, err := h.noteService.ResummarizeStale(context.WithoutCancel(ctx.Request.Context()), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to re-summarize notes: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// ListSummaryStyles lists the styles a summary can be requested in.
+func (h *NoteHandler) ListSummaryStyles(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"styles": llm.Styles(), "default": llm.DefaultStyle})
+}
+
+// StreamSummary regenerates the note's summary and pushes it to the client
+// as Server-Sent Events: "token" events while it is written, then "done"
+// with the saved summary or "error". Closing the connection cancels it.
+func (h *NoteHandler) StreamSummary(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	// the stream only starts with the first token so that a missing note
+	// still gets a plain 404
+	started := false
+	summary, err := h.noteService.StreamSummary(ctx.Request.Context(), uint(noteID), user.UserID, ctx.Query("style"), func(chunk string) error {
+		if !started {
+			sse.Begin(ctx)
+			started = true
+		}
+		return sse.Send(ctx, "token", gin.H{"text": chunk})
+	})
+	if err != nil {
+		switch {
+		case ctx.Request.Context().Err() != nil:
+		case started && errors.Is(err, llm.ErrBlocked):
+			sse.Send(ctx, "error", gin.H{"error": llm.ErrBlocked.Error()})
+		case started:
+			sse.Send(ctx, "error", gin.H{"error": "Failed to generate summary"})
+		case errors.Is(err, ErrNoteNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrUnknownStyle):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, llm.ErrQuotaExceeded):
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case errors.Is(err, llm.ErrCircuitOpen):
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, llm.ErrBlocked):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": llm.ErrBlocked.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate summary"})
+		}
+		return
+	}
+	if !started {
+		sse.Begin(ctx)
+	}
+	sse.Send(ctx, "done", gin.H{"summary": summary})
+}
+
+// ShareNote shares the note with a user by email, or changes the role of
+// an existing share. The reply is the same whether the email has an
+// account or not.
+func (h *NoteHandler) ShareNote(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+	var req ShareNoteDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.noteService.ShareNote(uint(noteID), user.UserID, req.Email, req.Role)
+	switch {
+	case errors.Is(err, ErrUnknownRole), errors.Is(err, ErrShareWithSelf):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share note"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "If the email has an account, the note is now shared with it", "email": strings.TrimSpace(req.Email), "role": req.Role})
+}
+
+func (h *NoteHandler) ListShares(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	shares, err := h.noteService.ListShares(uint(noteID), user.UserID)
+	switch {
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+// RemoveShare stops sharing the note with a user; users shared with may
+// remove themselves.
+func (h *NoteHandler) RemoveShare(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+	sharedWithID, err := strconv.ParseUint(ctx.Param("userId"), 10, 32)
+	if err != nil || sharedWithID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = h.noteService.RemoveShare(uint(noteID), user.UserID, uint(sharedWithID))
+	switch {
+	case errors.Is(err, ErrNoteNotFound), errors.Is(err, ErrShareNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove share"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// SharedWithMe lists the notes other users shared with the current user,
+// each with the role they were given.
+func (h *NoteHandler) SharedWithMe(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	notes, err := h.noteService.SharedWithMe(user.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"notes": notes})
+}
+
+// CreatePublicLink creates a read-only link to the note for people without
+// an account, optionally expiring and password protected.
+func (h *NoteHandler) CreatePublicLink(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+	var req CreatePublicLinkDTO
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, url, err := h.noteService.CreatePublicLink(uint(noteID), user.UserID, req.ExpiresAt, req.Password)
+	switch {
+	case errors.Is(err, ErrInvalidExpiry), errors.Is(err, ErrLinkPasswordLong):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrTooManyLinks):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link"})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Store this link now, it will not be shown again",
+		"url":     url,
+		"link":    link,
+	})
+}
+
+// ListPublicLinks lists the note's public links with their view counts.
+func (h *NoteHandler) ListPublicLinks(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	links, err := h.noteService.ListPublicLinks(uint(noteID), user.UserID)
+	switch {
+	case errors.Is(err, ErrNoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+func (h *NoteHandler) RevokePublicLink(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+	linkID, err := strconv.ParseUint(ctx.Param("linkId"), 10, 32)
+	if err != nil || linkID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	err = h.noteService.RevokePublicLink(uint(noteID), user.UserID, uint(linkID))
+	switch {
+	case errors.Is(err, ErrNoteNotFound), errors.Is(err, ErrLinkNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke link"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ViewPublicLink shows the note behind a public link to anyone, as a page
+// for browsers or as JSON for ?format=json or an Accept of
+// application/json. Passwords come in the X-Link-Password header or, from
+// the page's form, a posted password field.
+func (h *NoteHandler) ViewPublicLink(ctx *gin.Context) {
+	// the token in the URL must not leak to the images' hosts or caches
+	ctx.Header("Referrer-Policy", "no-referrer")
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("X-Robots-Tag", "noindex, nofollow")
+
+	asJSON := ctx.Query("format") == "json" ||
+		ctx.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+	password := ctx.GetHeader("X-Link-Password")
+	if ctx.Request.Method == http.MethodPost {
+		password = ctx.PostForm("password")
+	}
+
+	note, err := h.noteService.ViewPublicLink(ctx.Param("token"), password, ctx.ClientIP())
+	if err != nil {
+		status := http.StatusInternalServerError
+		message := "Something went wrong, please try again later."
+		switch {
+		case errors.Is(err, ErrLinkNotFound):
+			status, message = http.StatusNotFound, "This link does not exist or was revoked."
+		case errors.Is(err, ErrLinkExpired):
+			status, message = http.StatusGone, err.Error()
+		case errors.Is(err, ErrTooManyAttempts):
+			status, message = http.StatusTooManyRequests, err.Error()
+		case errors.Is(err, ErrPasswordRequired), errors.Is(err, ErrWrongPassword):
+			status, message = http.StatusUnauthorized, err.Error()
+		default:
+			log.Printf("note: public link view failed: %v", err)
+		}
+		if asJSON {
+			ctx.JSON(status, gin.H{"error": message})
+			return
+		}
+		if status == http.StatusUnauthorized {
+			prompt := ""
+			if errors.Is(err, ErrWrongPassword) {
+				prompt = "Wrong password, please try again."
+			}
+			page, err := PasswordPage(prompt)
+			if err == nil {
+				ctx.Data(status, "text/html; charset=utf-8", page)
+				return
+			}
+		}
+		ctx.Data(status, "text/html; charset=utf-8", []byte(publicErrorPage(message)))
+		return
+	}
+
+	if asJSON {
+		public, err := note.Public()
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render note"})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"note": public})
+		return
+	}
+	page, err := note.PublicPage()
+	if err != nil {
+		ctx.Data(http.StatusInternalServerError, "text/html; charset=utf-8", []byte(publicErrorPage("Something went wrong, please try again later.")))
+		return
+	}
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", page)
+}
+
+func publicErrorPage(message string) string {
+	return `<!DOCTYPE html><html><head><meta charset="utf-8"><title>NoteMind</title></head><body style="font-family: sans-serif; max-width: 480px; margin: 80px auto; text-align: center;"><p>` +
+		template.HTMLEscapeString(message) + `</p></body></html>`
+}
@@ -0,0 +1,107 @@
+package note
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"notemind/internal/llm"
+	"notemind/internal/media"
+)
+
+// ImportedNote is a note brought over from another app.
+type ImportedNote struct {
+	Title     string
+	Content   string
+	Format    string
+	Tags      []string
+	Images    []ImportedImage
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ImportedImage is an image of an imported note. When Ref is set, it is
+// replaced in the note's content by the URL the image is uploaded to, so
+// references to it keep working.
+type ImportedImage struct {
+	Ref  string
+	Data []byte
+}
+
+func (s *noteService) ImportNote(ctx context.Context, userID uint, in *ImportedNote) (*Note, error) {
+	if userID == 0 {
+		return nil, errors.New("user ID cannot be zero")
+	}
+	format := in.Format
+	if format == "" {
+		format = FormatPlain
+	}
+	if !ValidFormat(format) {
+		return nil, ErrUnknownFormat
+	}
+
+	uploaded := make([]*media.UploadResult, 0, len(in.Images))
+	content := in.Content
+	for i, img := range in.Images {
+		result, err := s.images.Upload(ctx, bytes.NewReader(img.Data), "notes")
+		if err != nil {
+			s.discardImages(uploaded)
+			return nil, fmt.Errorf("failed to upload image %d: %w", i+1, err)
+		}
+		uploaded = append(uploaded, result)
+		if img.Ref != "" {
+			content = strings.ReplaceAll(content, img.Ref, result.URL)
+		}
+	}
+
+	now := time.Now().UTC()
+	note := &Note{
+		UserID:       userID,
+		Title:        in.Title,
+		SummaryStyle: llm.DefaultStyle,
+		// left to the pending summary job, which keeps within the user's quota
+		SummaryStatus: SummaryPending,
+		CreatedAt:     in.CreatedAt.UTC(),
+		UpdatedAt:     in.UpdatedAt.UTC(),
+	}
+	if in.CreatedAt.IsZero() {
+		note.CreatedAt = now
+	}
+	if in.UpdatedAt.IsZero() {
+		note.UpdatedAt = note.CreatedAt
+	}
+	note.setContent(content, format)
+
+	if err := s.repo.Create(note); err != nil {
+		s.discardImages(uploaded)
+		return nil, err
+	}
+	for _, result := range uploaded {
+		image := NoteImage{NoteID: note.ID, ImageURL: result.URL, PublicID: result.PublicID, UploadedAt: now}
+		if err := s.repo.CreateImg(&image); err != nil {
+			return nil, err
+		}
+		note.Images = append(note.Images, image)
+	}
+	if tags := normalizeTags(in.Tags); len(tags) > 0 {
+		saved, err := s.repo.SetTags(note.ID, userID, tags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tag note: %w", err)
+		}
+		note.Tags = saved
+	}
+	return note, nil
+}
+
+// discardImages deletes images uploaded for a note that could not be saved.
+func (s *noteService) discardImages(uploaded []*media.UploadResult) {
+	for _, result := range uploaded {
+		if err := s.images.Delete(context.Background(), result.PublicID); err != nil {
+			log.Printf("note: failed to delete orphaned image %s: %v", result.PublicID, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package note
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var slugUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// frontMatter is the YAML header of an exported Markdown note, which the
+// importer reads back.
+type frontMatter struct {
+	Title         string   `yaml:"title"`
+	Tags          []string `yaml:"tags,omitempty"`
+	CreatedAt     string   `yaml:"created_at"`
+	UpdatedAt     string   `yaml:"updated_at"`
+	ContentFormat string   `yaml:"content_format"`
+	Summary       string   `yaml:"summary,omitempty"`
+	Images        []string `yaml:"images,omitempty"`
+}
+
+// Markdown renders a note as a standalone Markdown document, with its
+// title, dates, tags, summary and images in the front matter so that it
+// can be imported again.
+func (n *Note) Markdown() string {
+	return n.markdown(nil)
+}
+
+// markdown is Markdown with the images in files referenced by their path.
+func (n *Note) markdown(files map[uint]string) string {
+	title := n.Title
+	if title == "" {
+		title = "Untitled note"
+	}
+	fm := frontMatter{
+		Title:         title,
+		Tags:          n.TagNames(),
+		CreatedAt:     n.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:     n.UpdatedAt.UTC().Format(time.RFC3339),
+		ContentFormat: n.ContentFormat,
+		Summary:       strings.TrimSpace(n.Summary),
+	}
+	if fm.ContentFormat == "" {
+		fm.ContentFormat = FormatPlain
+	}
+	for _, img := range n.Images {
+		fm.Images = append(fm.Images, imageSrc(img, files))
+	}
+	// a struct of strings always marshals
+	header, _ := yaml.Marshal(fm)
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.Write(header)
+	b.WriteString("---\n\n")
+	if content := strings.TrimSpace(n.Content); content != "" {
+		b.WriteString(content)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// imageSrc is where an exported note finds img: its copy when there is one.
+func imageSrc(img NoteImage, files map[uint]string) string {
+	if file, ok := files[img.ID]; ok {
+		return file
+	}
+	return img.ImageURL
+}
+
+// FileName is a stable, filesystem safe name for the note without extension.
+func (n *Note) FileName() string {
+	slug := strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(n.Title), "-"), "-")
+	if len(slug) > 60 {
+		slug = strings.Trim(slug[:60], "-")
+	}
+	if slug == "" {
+		return fmt.Sprintf("%d", n.ID)
+	}
+	return fmt.Sprintf("%d-%s", n.ID, slug)
+}
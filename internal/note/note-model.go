@@ -2,14 +2,39 @@ package note
 
 import "time"
 
+// Summary states. A pending summary is written once the user's daily AI
+// quota allows it again; a blocked one was refused by the model's safety
+// filters and is not retried until the note changes.
+const (
+	SummaryReady   = "ready"
+	SummaryPending = "pending"
+	SummaryFailed  = "failed"
+	SummaryBlocked = "blocked"
+)
+
 type Note struct {
 	ID      uint   `json:"id" gorm:"primaryKey"`
 	UserID  uint   `json:"user_id"` //foreign key
 	Title   string `json:"title"`
 	Content string `json:"content"`
-	Summary string `json:"summary"`
+	// ContentFormat is one of the Format constants, ContentText the plain
+	// text projection of Content used for search and summaries.
+	ContentFormat string `json:"content_format"`
+	ContentText   string `json:"-"`
+	Summary       string `json:"summary"`
+	// SummaryStyle is the llm style the summary is written in.
+	SummaryStyle  string `json:"summary_style"`
+	SummaryStatus string `json:"summary_status"`
+	// ContentHash and PromptVersion identify what the summary was generated
+	// from, so identical text is not summarized twice and summaries from an
+	// outdated prompt can be found.
+	ContentHash   string `json:"-"`
+	PromptVersion int    `json:"prompt_version"`
+	// Role is what the user who loaded the note may do with it.
+	Role string `json:"role,omitempty" gorm:"->"`
 
 	Images    []NoteImage `json:"images,omitempty" gorm:"foreignKey:NoteID"`
+	Tags      []Tag       `json:"tags,omitempty" gorm:"many2many:note_tags"`
 	CreatedAt time.Time   `json:"created_at"`
 	UpdatedAt time.Time   `json:"updated_at"`
 }
@@ -18,6 +43,7 @@ type NoteImage struct {
 	ID       uint   `json:"id" gorm:"primaryKey"`
 	NoteID   uint   `json:"note_id"` // foreign key
 	ImageURL string `json:"image_url"`
+	PublicID string `json:"-"`
 
 	UploadedAt time.Time `json:"uploaded_at"`
 }
@@ -0,0 +1,251 @@
+package note
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxPublicLinksPerNote = 20
+	// bcrypt ignores anything past 72 bytes
+	maxLinkPasswordBytes = 72
+	defaultAppBaseURL    = "http://localhost:8080"
+	// wrong passwords allowed per link and per client IP in a window
+	maxLinkAttempts   = 10
+	maxClientAttempts = 30
+	attemptWindowSize = 15 * time.Minute
+)
+
+var (
+	ErrLinkNotFound     = errors.New("link not found")
+	ErrLinkExpired      = errors.New("this link has expired")
+	ErrPasswordRequired = errors.New("this note is password protected")
+	ErrWrongPassword    = errors.New("wrong password")
+	ErrTooManyAttempts  = errors.New("too many wrong passwords, please try again later")
+	ErrInvalidExpiry    = errors.New("expires_at must be in the future")
+	ErrLinkPasswordLong = fmt.Errorf("password must be at most %d bytes", maxLinkPasswordBytes)
+	ErrTooManyLinks     = fmt.Errorf("a note can have at most %d active public links", maxPublicLinksPerNote)
+)
+
+var passwordPage = template.Must(template.ParseFS(templateFS, "templates/password.html.tmpl"))
+
+// PublicLink lets anyone with its token read a note without an account.
+// Only a hash of the token is stored, so the link is shown once, when it
+// is created.
+type PublicLink struct {
+	ID           uint    `json:"id" gorm:"primaryKey"`
+	NoteID       uint    `json:"note_id"`
+	TokenHash    string  `json:"-"`
+	PasswordHash *string `json:"-"`
+	// PasswordProtected is read along with the links of a note.
+	PasswordProtected bool       `json:"password_protected" gorm:"->"`
+	CreatedBy         *uint      `json:"-"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	ViewCount         int        `json:"view_count"`
+	LastViewedAt      *time.Time `json:"last_viewed_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+func (PublicLink) TableName() string {
+	return "note_public_links"
+}
+
+func (l *PublicLink) Active(now time.Time) bool {
+	if l.RevokedAt != nil {
+		return false
+	}
+	return l.ExpiresAt == nil || now.Before(*l.ExpiresAt)
+}
+
+// PublicNote is what a public link shows of a note: no tags, owner or
+// other account details.
+type PublicNote struct {
+	Title         string        `json:"title"`
+	Content       string        `json:"content"`
+	ContentFormat string        `json:"content_format"`
+	HTML          template.HTML `json:"html"`
+	Summary       string        `json:"summary"`
+	Images        []string      `json:"images"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+func (s *noteService) CreatePublicLink(noteID, ownerID uint, expiresAt *time.Time, password string) (*PublicLink, string, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidExpiry
+	}
+	if len(password) > maxLinkPasswordBytes {
+		return nil, "", ErrLinkPasswordLong
+	}
+	if _, err := s.access(noteID, ownerID, RoleOwner); err != nil {
+		return nil, "", err
+	}
+	active, err := s.repo.CountActivePublicLinks(noteID)
+	if err != nil {
+		return nil, "", err
+	}
+	if active >= maxPublicLinksPerNote {
+		return nil, "", ErrTooManyLinks
+	}
+
+	rawToken, err := generateLinkToken()
+	if err != nil {
+		return nil, "", err
+	}
+	link := &PublicLink{
+		NoteID:    noteID,
+		TokenHash: hashLinkToken(rawToken),
+		CreatedBy: &ownerID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now().UTC(),
+	}
+	if link.ExpiresAt != nil {
+		utc := link.ExpiresAt.UTC()
+		link.ExpiresAt = &utc
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", err
+		}
+		hashed := string(hash)
+		link.PasswordHash = &hashed
+	}
+	link.PasswordProtected = link.PasswordHash != nil
+	if err := s.repo.CreatePublicLink(link); err != nil {
+		return nil, "", fmt.Errorf("failed to create link: %w", err)
+	}
+	return link, publicLinkURL(rawToken), nil
+}
+
+func (s *noteService) ListPublicLinks(noteID, ownerID uint) ([]PublicLink, error) {
+	if _, err := s.access(noteID, ownerID, RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPublicLinks(noteID)
+}
+
+func (s *noteService) RevokePublicLink(noteID, ownerID, linkID uint) error {
+	if _, err := s.access(noteID, ownerID, RoleOwner); err != nil {
+		return err
+	}
+	revoked, err := s.repo.RevokePublicLink(noteID, linkID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+func (s *noteService) ViewPublicLink(rawToken, password, clientIP string) (*Note, error) {
+	tokenHash := hashLinkToken(rawToken)
+	link, err := s.repo.GetPublicLink(tokenHash)
+	if err != nil || link.RevokedAt != nil {
+		return nil, ErrLinkNotFound
+	}
+	now := time.Now()
+	if !link.Active(now) {
+		return nil, ErrLinkExpired
+	}
+	if link.PasswordHash != nil {
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		if !s.linkAttempts.reserve(tokenHash, now) {
+			return nil, ErrTooManyAttempts
+		}
+		if !s.clientAttempts.reserve(clientIP, now) {
+			s.linkAttempts.refund(tokenHash)
+			return nil, ErrTooManyAttempts
+		}
+		if bcrypt.CompareHashAndPassword([]byte(*link.PasswordHash), []byte(password)) != nil {
+			return nil, ErrWrongPassword
+		}
+		s.linkAttempts.refund(tokenHash)
+		s.clientAttempts.refund(clientIP)
+	}
+
+	note, err := s.repo.GetByID(link.NoteID)
+	if err != nil {
+		return nil, ErrLinkNotFound
+	}
+	if err := s.repo.RecordPublicView(link.ID, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// Public is the note as a public link returns it in JSON.
+func (n *Note) Public() (*PublicNote, error) {
+	html, err := n.HTML()
+	if err != nil {
+		return nil, err
+	}
+	public := &PublicNote{
+		Title:         n.Title,
+		Content:       n.Content,
+		ContentFormat: n.ContentFormat,
+		HTML:          html,
+		Summary:       n.Summary,
+		Images:        make([]string, 0, len(n.Images)),
+		CreatedAt:     n.CreatedAt,
+		UpdatedAt:     n.UpdatedAt,
+	}
+	for _, img := range n.Images {
+		public.Images = append(public.Images, img.ImageURL)
+	}
+	return public, nil
+}
+
+// PublicPage renders the note the way a public link shows it in a browser,
+// the same page as the HTML export without the tags.
+func (n *Note) PublicPage() ([]byte, error) {
+	shown := *n
+	shown.Tags = nil
+	return shown.exportHTML(nil)
+}
+
+// PasswordPage renders the form a password protected link asks for its
+// password with, posting it back to the link.
+func PasswordPage(message string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := passwordPage.Execute(&buf, message); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func generateLinkToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashLinkToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
+
+func publicLinkURL(rawToken string) string {
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultAppBaseURL
+	}
+	return strings.TrimRight(baseURL, "/") + "/p/" + rawToken
+}
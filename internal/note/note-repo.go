@@ -1,7 +1,11 @@
 package note
 
 import (
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NoteRepo interface {
@@ -11,6 +15,43 @@ type NoteRepo interface {
 	Delete(id uint) error 
 	GetByID(id uint) (*Note, error)
 	DeleteImagesByNoteID(id uint) error
+	// FindSummary returns the summary of one of the user's notes with the
+	// same content hash and prompt version, or "" if there is none.
+	FindSummary(userID uint, contentHash string, promptVersion int) (string, error)
+	// ListStale returns up to limit notes whose summary was written with an
+	// older prompt version than the current one of its style.
+	ListStale(versions map[string]int, limit int) ([]Note, error)
+	// ListPending returns up to limit notes waiting for their summary.
+	ListPending(limit int) ([]Note, error)
+	// UpdateSummary stores a regenerated summary without touching anything
+	// else, updated_at included, since the user did not edit the note.
+	UpdateSummary(note *Note) error
+	// SetTags replaces the note's tags by the user's tags named names,
+	// creating those that do not exist yet.
+	SetTags(noteID, userID uint, names []string) ([]Tag, error)
+	ListTags(userID uint) ([]Tag, error)
+	GetTag(userID, tagID uint) (*Tag, error)
+	// ListByTag returns up to limit of the user's notes tagged tagID, oldest
+	// first, with their images and tags.
+	ListByTag(userID, tagID uint, limit int) ([]Note, error)
+
+	GetShare(noteID, userID uint) (*Share, error)
+	// SaveShare creates the share or changes the role of an existing one.
+	SaveShare(share *Share) error
+	ListShares(noteID uint) ([]Share, error)
+	DeleteShare(noteID, userID uint) (bool, error)
+	// ListSharedWith returns the notes shared with the user, their Role set.
+	ListSharedWith(userID uint) ([]Note, error)
+	FindUserByEmail(email string) (*ShareUser, error)
+
+	CreatePublicLink(link *PublicLink) error
+	// GetPublicLink finds a link by its token hash, unless the owner of its
+	// note deleted their account or asked to.
+	GetPublicLink(tokenHash string) (*PublicLink, error)
+	ListPublicLinks(noteID uint) ([]PublicLink, error)
+	CountActivePublicLinks(noteID uint) (int64, error)
+	RevokePublicLink(noteID, linkID uint, at time.Time) (bool, error)
+	RecordPublicView(linkID uint, at time.Time) error
 }
 
 type noterepo struct {
@@ -31,20 +72,214 @@ func (r *noterepo) CreateImg(noteImage *NoteImage) error {
 
 func (r *noterepo) GetByID(id uint) (*Note, error) {
 	var note Note
-	if err := r.db.Preload("Images").First(&note, id).Error; err != nil {
+	if err := r.db.Preload("Images").Preload("Tags").First(&note, id).Error; err != nil {
 		return nil, err
 	}
 	return &note, nil
 }
 
 func (r *noterepo) Update(note *Note) error {
-	return r.db.Save(note).Error
+	// tags are only changed through SetTags
+	return r.db.Omit("Tags").Save(note).Error
 }
 
 func (r *noterepo) DeleteImagesByNoteID(noteID uint) error {
 	return r.db.Where("note_id = ?", noteID).Delete(&NoteImage{}).Error
 }
 
+func (r *noterepo) FindSummary(userID uint, contentHash string, promptVersion int) (string, error) {
+	var summaries []string
+	err := r.db.Model(&Note{}).
+		Where("user_id = ? AND content_hash = ? AND prompt_version = ? AND summary_status = ?", userID, contentHash, promptVersion, SummaryReady).
+		Limit(1).
+		Pluck("summary", &summaries).Error
+	if err != nil || len(summaries) == 0 {
+		return "", err
+	}
+	return summaries[0], nil
+}
+
+func (r *noterepo) ListStale(versions map[string]int, limit int) ([]Note, error) {
+	stale := r.db.Where("1 = 0")
+	for style, version := range versions {
+		stale = stale.Or("summary_style = ? AND prompt_version < ?", style, version)
+	}
+
+	var notes []Note
+	err := r.db.Where(stale).Order("id").Limit(limit).Find(&notes).Error
+	return notes, err
+}
+
+func (r *noterepo) ListPending(limit int) ([]Note, error) {
+	var notes []Note
+	err := r.db.Where("summary_status = ?", SummaryPending).Order("id").Limit(limit).Find(&notes).Error
+	return notes, err
+}
+
+func (r *noterepo) UpdateSummary(note *Note) error {
+	return r.db.Model(note).Select("summary", "summary_status", "content_hash", "prompt_version").UpdateColumns(note).Error
+}
+
+func (r *noterepo) SetTags(noteID, userID uint, names []string) ([]Tag, error) {
+	tags := []Tag{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM note_tags WHERE note_id = ?", noteID).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		keys := make([]string, len(names))
+		created := make([]Tag, len(names))
+		for i, name := range names {
+			keys[i] = strings.ToLower(name)
+			created[i] = Tag{UserID: userID, Name: name}
+		}
+		// names differing from an existing tag only in case reuse that tag
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND lower(name) IN ?", userID, keys).Order("name").Find(&tags).Error; err != nil {
+			return err
+		}
+
+		links := make([]map[string]interface{}, len(tags))
+		for i, tag := range tags {
+			links[i] = map[string]interface{}{"note_id": noteID, "tag_id": tag.ID}
+		}
+		return tx.Table("note_tags").Create(links).Error
+	})
+	return tags, err
+}
+
+func (r *noterepo) ListTags(userID uint) ([]Tag, error) {
+	var tags []Tag
+	err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func (r *noterepo) GetTag(userID, tagID uint) (*Tag, error) {
+	var tag Tag
+	if err := r.db.Where("id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *noterepo) ListByTag(userID, tagID uint, limit int) ([]Note, error) {
+	var notes []Note
+	err := r.db.Preload("Images").Preload("Tags").
+		Where("user_id = ? AND id IN (?)", userID, r.db.Table("note_tags").Select("note_id").Where("tag_id = ?", tagID)).
+		Order("created_at").
+		Limit(limit).
+		Find(&notes).Error
+	return notes, err
+}
+
+func (r *noterepo) GetShare(noteID, userID uint) (*Share, error) {
+	var share Share
+	if err := r.db.Where("note_id = ? AND user_id = ?", noteID, userID).First(&share).Error; err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *noterepo) SaveShare(share *Share) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "note_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(share).Error
+}
+
+func (r *noterepo) ListShares(noteID uint) ([]Share, error) {
+	var shares []Share
+	err := r.db.Table("note_shares").
+		Select("note_shares.*, users.email, users.name").
+		Joins("JOIN users ON users.id = note_shares.user_id").
+		Where("note_shares.note_id = ?", noteID).
+		Order("note_shares.created_at").
+		Find(&shares).Error
+	return shares, err
+}
+
+func (r *noterepo) DeleteShare(noteID, userID uint) (bool, error) {
+	res := r.db.Where("note_id = ? AND user_id = ?", noteID, userID).Delete(&Share{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *noterepo) ListSharedWith(userID uint) ([]Note, error) {
+	var notes []Note
+	err := r.db.Preload("Images").
+		Select("notes.*, note_shares.role").
+		Joins("JOIN note_shares ON note_shares.note_id = notes.id").
+		Where("note_shares.user_id = ?", userID).
+		Order("notes.updated_at DESC").
+		Find(&notes).Error
+	return notes, err
+}
+
+func (r *noterepo) FindUserByEmail(email string) (*ShareUser, error) {
+	var user ShareUser
+	err := r.db.Table("users").
+		Select("id, name, email").
+		Where("lower(email) = lower(?) AND deleted_at IS NULL AND deletion_requested_at IS NULL", email).
+		Take(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *noterepo) CreatePublicLink(link *PublicLink) error {
+	return r.db.Create(link).Error
+}
+
+func (r *noterepo) GetPublicLink(tokenHash string) (*PublicLink, error) {
+	var link PublicLink
+	err := r.db.Select("note_public_links.*").
+		Joins("JOIN notes ON notes.id = note_public_links.note_id").
+		Joins("JOIN users ON users.id = notes.user_id").
+		Where("note_public_links.token_hash = ?", tokenHash).
+		Where("users.deletion_requested_at IS NULL AND users.deleted_at IS NULL").
+		First(&link).Error
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *noterepo) ListPublicLinks(noteID uint) ([]PublicLink, error) {
+	var links []PublicLink
+	err := r.db.Select("*, password_hash IS NOT NULL AS password_protected").
+		Where("note_id = ?", noteID).
+		Order("created_at DESC").
+		Find(&links).Error
+	return links, err
+}
+
+func (r *noterepo) CountActivePublicLinks(noteID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&PublicLink{}).
+		Where("note_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", noteID, time.Now().UTC()).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *noterepo) RevokePublicLink(noteID, linkID uint, at time.Time) (bool, error) {
+	res := r.db.Model(&PublicLink{}).
+		Where("id = ? AND note_id = ? AND revoked_at IS NULL", linkID, noteID).
+		Update("revoked_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *noterepo) RecordPublicView(linkID uint, at time.Time) error {
+	return r.db.Model(&PublicLink{}).Where("id = ?", linkID).Updates(map[string]interface{}{
+		"view_count":     gorm.Expr("view_count + 1"),
+		"last_viewed_at": at,
+	}).Error
+}
+
 func(r *noterepo) Delete(id uint) error {
 	return r.db.Delete(&Note{}, id).Error 
 }
\ No newline at end of file
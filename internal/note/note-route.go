@@ -1,14 +1,39 @@
 package note
 
 import (
-	"notemind/internal/middleware"
+	"notemind/internal/token"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetUpRoutes(router *gin.Engine, notehandler *NoteHandler) {
+func SetUpRoutes(router *gin.Engine, notehandler *NoteHandler, authMiddleware gin.HandlerFunc) {
 	v1 := router.Group("/api/v1")
-	v1.POST("/notes", middleware.AuthMiddleware(), notehandler.CreateNote)
-	v1.PUT("/notes/:id", middleware.AuthMiddleware(),notehandler.UpdateNote)
-	v1.GET("/notes/:id",middleware.AuthMiddleware(), notehandler.GetOneNote)
+	v1.POST("/notes", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.CreateNote)
+	v1.PUT("/notes/:id", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.UpdateNote)
+	v1.GET("/notes/shared-with-me", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.SharedWithMe)
+	v1.GET("/notes/:id", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.GetOneNote)
+	v1.GET("/notes/:id/html", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.RenderNote)
+	v1.GET("/notes/:id/summary/stream", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.StreamSummary)
+	v1.POST("/notes/:id/summarize", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.Summarize)
+	v1.PUT("/notes/:id/tags", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.SetTags)
+	v1.GET("/notes/:id/export", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.ExportNote)
+	v1.POST("/notes/:id/shares", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.ShareNote)
+	v1.GET("/notes/:id/shares", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.ListShares)
+	v1.DELETE("/notes/:id/shares/:userId", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.RemoveShare)
+	v1.POST("/notes/:id/public-link", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.CreatePublicLink)
+	v1.GET("/notes/:id/public-links", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.ListPublicLinks)
+	v1.DELETE("/notes/:id/public-links/:linkId", authMiddleware, token.RequireScope(token.ScopeNotesWrite), notehandler.RevokePublicLink)
+	v1.GET("/tags", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.ListTags)
+	v1.GET("/tags/:id/export", authMiddleware, token.RequireScope(token.ScopeNotesRead), notehandler.ExportTag)
+	v1.GET("/summary-styles", authMiddleware, notehandler.ListSummaryStyles)
+
+	// public links are read without an account
+	router.GET("/p/:token", notehandler.ViewPublicLink)
+	router.POST("/p/:token", notehandler.ViewPublicLink)
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(authMiddleware, token.RequireRole(token.RoleAdmin))
+	{
+		admin.POST("/notes/resummarize", notehandler.ResummarizeStale)
+	}
 }
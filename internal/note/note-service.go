@@ -4,112 +4,184 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html/template"
 	"mime/multipart"
-	"os"
 	"time"
 	"log"
 
 	"notemind/internal/llm"
+	"notemind/internal/mailer"
+	"notemind/internal/media"
 	"notemind/internal/voice"
+)
 
-	"github.com/cloudinary/cloudinary-go/v2"
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+var (
+	ErrNoteNotFound  = errors.New("note not found")
+	ErrUnknownStyle  = errors.New("unknown summary style")
+	ErrUnknownFormat = errors.New("unknown content format")
 )
 
 type NoteService interface {
-	CreateNote(userID uint, title string, content string, imageFile *multipart.FileHeader) (*Note, error)
-	CreateVoiceNote(userID uint, audioFile *multipart.FileHeader, title string, imageFile *multipart.FileHeader) (*Note, error)
-	UpdateNote(noteID uint, userID uint, title, content string, imageFile *multipart.FileHeader) error
-	GetOneNote(id uint) (*Note, error)
+	// An empty style means llm.DefaultStyle on create and the note's current style on update,
+	// an empty format FormatPlain on create and the note's current format on update.
+	CreateNote(ctx context.Context, userID uint, title string, content string, format string, style string, imageFile *multipart.FileHeader) (*Note, error)
+	CreateVoiceNote(ctx context.Context, userID uint, audioFile *multipart.FileHeader, title string, style string, imageFile *multipart.FileHeader) (*Note, error)
+	UpdateNote(ctx context.Context, noteID uint, userID uint, title, content, format, style string, imageFile *multipart.FileHeader) error
+	GetOneNote(id uint, userID uint) (*Note, error)
+	// RenderNote renders the content of a note the user can view as sanitized HTML.
+	RenderNote(noteID, userID uint) (*Note, template.HTML, error)
+	// ImportNote stores a note from another app with its original dates. Its
+	// summary is left pending so that a large import does not use up the
+	// user's AI quota at once, and save hooks are not run.
+	ImportNote(ctx context.Context, userID uint, in *ImportedNote) (*Note, error)
+	SetTags(noteID, userID uint, names []string) ([]Tag, error)
+	ListTags(userID uint) ([]Tag, error)
+	// ExportNote renders a note the user can view in one of the export formats.
+	ExportNote(noteID, userID uint, format string) (*Note, []byte, error)
+	// NotesByTag returns the user's notes tagged tagID for an export, failing
+	// with ErrExportTooLarge past maxExportNotes.
+	NotesByTag(userID, tagID uint) (*Tag, []Note, error)
+	// Summarize rewrites the summary of a note the user can edit in style and keeps
+	// the style for later updates.
+	Summarize(ctx context.Context, noteID, userID uint, style string) (*Note, error)
+	// StreamSummary regenerates the summary of a note the user can edit, passing it to
+	// onChunk as it is generated, and saves it once complete.
+	StreamSummary(ctx context.Context, noteID, userID uint, style string, onChunk func(string) error) (string, error)
+	// ResummarizeStale regenerates up to limit summaries written with an
+	// outdated prompt version.
+	ResummarizeStale(ctx context.Context, limit int) (*ResummarizeReport, error)
+	// SummarizePending writes up to limit summaries postponed because their
+	// owner ran out of quota, as far as the quota allows by now.
+	SummarizePending(ctx context.Context, limit int) (*ResummarizeReport, error)
+	// ShareNote gives the user with email role on the owner's note, or
+	// changes the role they have, and lets them know by email. An email
+	// without an account succeeds all the same and shares nothing.
+	ShareNote(noteID, ownerID uint, email, role string) error
+	ListShares(noteID, ownerID uint) ([]Share, error)
+	// RemoveShare takes the note away from sharedWithID. The owner can remove
+	// anyone, other users only themselves.
+	RemoveShare(noteID, userID, sharedWithID uint) error
+	SharedWithMe(userID uint) ([]Note, error)
+	// CreatePublicLink creates a link anyone can read the owner's note with,
+	// returning its URL, which cannot be retrieved later.
+	CreatePublicLink(noteID, ownerID uint, expiresAt *time.Time, password string) (*PublicLink, string, error)
+	ListPublicLinks(noteID, ownerID uint) ([]PublicLink, error)
+	RevokePublicLink(noteID, ownerID, linkID uint) error
+	// ViewPublicLink returns the note a public link points to and counts the
+	// view, checking the password of protected links. Wrong passwords are
+	// throttled per link and per clientIP.
+	ViewPublicLink(rawToken, password, clientIP string) (*Note, error)
+	// OnSave registers a hook called after a note is created or its text changes.
+	OnSave(hook SaveHook)
+}
+
+// SaveHook lets other features react to note changes without the note
+// package depending on them. Hooks run on the request path and should hand
+// slow work off to the background.
+type SaveHook interface {
+	NoteSaved(note *Note)
 }
 
 type noteService struct {
 	repo        NoteRepo
-	llmservice  *llm.LLMService
+	llmservice  llm.Client
 	transcriber voice.Transcriber
+	images      media.Store
+	hooks       []SaveHook
+	mail        mailer.Mailer
+	// wrong public link passwords, by link and by client IP
+	linkAttempts   *attemptLimiter
+	clientAttempts *attemptLimiter
 }
 
-func NewNoteService(repo NoteRepo, llmService *llm.LLMService, transcriber voice.Transcriber) NoteService {
+func NewNoteService(repo NoteRepo, llmService llm.Client, transcriber voice.Transcriber, images media.Store, mail mailer.Mailer) NoteService {
 	return &noteService{
-		repo:        repo,
-		llmservice:  llmService,
-		transcriber: transcriber,
+		repo:           repo,
+		llmservice:     llmService,
+		transcriber:    transcriber,
+		images:         images,
+		mail:           mail,
+		linkAttempts:   newAttemptLimiter(maxLinkAttempts, attemptWindowSize),
+		clientAttempts: newAttemptLimiter(maxClientAttempts, attemptWindowSize),
 	}
 }
 
-// ...existing code...
+func (s *noteService) OnSave(hook SaveHook) {
+	s.hooks = append(s.hooks, hook)
+}
 
-func (s *noteService) handleImageUpload(noteID uint, imageFile *multipart.FileHeader) error {
+func (s *noteService) notifySaved(note *Note) {
+	for _, hook := range s.hooks {
+		hook.NoteSaved(note)
+	}
+}
+
+func (s *noteService) handleImageUpload(ctx context.Context, noteID uint, imageFile *multipart.FileHeader) error {
 	src, err := imageFile.Open()
 	if err != nil {
 		return err
 	}
 	defer src.Close()
 
-	cld, err := cloudinary.NewFromParams(
-		os.Getenv("CLOUDINARY_CLOUD_NAME"),
-		os.Getenv("CLOUDINARY_API_KEY"),
-		os.Getenv("CLOUDINARY_API_SECRET"),
-	)
+	result, err := s.images.Upload(ctx, src, "notes")
 	if err != nil {
-		return errors.New("cloudinary config failed")
-	}
-
-	result, err := cld.Upload.Upload(
-		context.Background(),
-		src,
-		uploader.UploadParams{
-			ResourceType: "image",
-			Folder:       "notes",
-		},
-	)
-	if err != nil {
-		return errors.New("upload failed: " + err.Error())
+		return err
 	}
 
 	noteImage := &NoteImage{
 		NoteID:     noteID,
-		ImageURL:   result.SecureURL,
+		ImageURL:   result.URL,
+		PublicID:   result.PublicID,
 		UploadedAt: time.Now(),
 	}
 
 	return s.repo.CreateImg(noteImage)
 }
 
-func (s *noteService) CreateNote(userID uint, title, content string, imageFile *multipart.FileHeader) (*Note,error) {
+func (s *noteService) CreateNote(ctx context.Context, userID uint, title, content, format, style string, imageFile *multipart.FileHeader) (*Note,error) {
 	if userID == 0 {
 		return nil, errors.New("user ID can not become zero")
 	}
-	noteText := fmt.Sprintf("Title: %s\nContent: %s", title, content)
-	log.Println(noteText)
-	summary, err := s.llmservice.GenerateNoteSummary(noteText)
-
-	if err != nil {
-		// If summary generation fails, continue without summary
-		summary = "Summary generation failed"
+	if style == "" {
+		style = llm.DefaultStyle
+	}
+	if !llm.ValidStyle(style) {
+		return nil, ErrUnknownStyle
+	}
+	if format == "" {
+		format = FormatPlain
+	}
+	if !ValidFormat(format) {
+		return nil, ErrUnknownFormat
 	}
 	note := &Note{
 		UserID:    userID,
 		Title:     title,
-		Content:   content,
-		Summary:   summary,
+		SummaryStyle: style,
 		CreatedAt: time.Now().UTC(),
 		UpdatedAt: time.Now().UTC(),
 	}
+	note.setContent(content, format)
+	if err := s.summarize(ctx, note); err != nil && note.SummaryStatus != SummaryPending {
+		// If summary generation fails, continue without summary
+		note.Summary = "Summary generation failed"
+		note.SummaryStatus = SummaryFailed
+	}
 
 	if err := s.repo.Create(note); err != nil {
 		return nil,err
 	}
 
 	if imageFile != nil {
-		if err := s.handleImageUpload(note.ID, imageFile); err != nil {
+		if err := s.handleImageUpload(ctx, note.ID, imageFile); err != nil {
 			return nil, err
 		}
 	}
+	s.notifySaved(note)
 	return note, nil
 }
 
-func (s *noteService) CreateVoiceNote(userID uint, audioFile *multipart.FileHeader, title string, imageFile *multipart.FileHeader) (*Note, error) {
+func (s *noteService) CreateVoiceNote(ctx context.Context, userID uint, audioFile *multipart.FileHeader, title, style string, imageFile *multipart.FileHeader) (*Note, error) {
 	if userID == 0 {
 		return nil,errors.New("user ID cannot be zero")
 	}
@@ -122,7 +194,7 @@ func (s *noteService) CreateVoiceNote(userID uint, audioFile *multipart.FileHead
 		return nil,err
 	}
 
-	transcript, err := s.transcriber.Transcribe(context.Background(), audio)
+	transcript, err := s.transcriber.Transcribe(ctx, audio)
 	if err != nil {
 		return nil,err
 	}
@@ -132,12 +204,12 @@ func (s *noteService) CreateVoiceNote(userID uint, audioFile *multipart.FileHead
 		noteTitle = audioFile.Filename
 	}
 
-	return s.CreateNote(userID, noteTitle, transcript, imageFile)
+	return s.CreateNote(ctx, userID, noteTitle, transcript, FormatPlain, style, imageFile)
 }
 
 // Add this to your existing NoteService interface:
 
-func (s *noteService) UpdateNote(noteID uint, userID uint, title string, content string, imageFile *multipart.FileHeader) error {
+func (s *noteService) UpdateNote(ctx context.Context, noteID uint, userID uint, title, content, format, style string, imageFile *multipart.FileHeader) error {
 	if userID == 0 {
 		return errors.New("user ID cannot be zero")
 	}
@@ -146,43 +218,55 @@ func (s *noteService) UpdateNote(noteID uint, userID uint, title string, content
 		return errors.New("note ID cannot be zero")
 	}
 
-	// STEP 1: Get existing note and verify ownership
-	existingNote, err := s.repo.GetByID(noteID)
+	// STEP 1: Get existing note and verify the user may edit it
+	existingNote, err := s.access(noteID, userID, RoleEditor)
 	if err != nil {
-		return errors.New("note not found")
+		return err
 	}
 
-	// Check if user owns this note
-	if existingNote.UserID != userID {
-		return errors.New("unauthorized: you don't own this note")
+	if style == "" {
+		style = existingNote.SummaryStyle
 	}
-
-	// STEP 2: Generate new summary if content changed
-	var summary string
-	if content != existingNote.Content || title != existingNote.Title {
-		noteText := fmt.Sprintf("Title: %s\nContent: %s", title, content)
-		summary, err = s.llmservice.GenerateNoteSummary(noteText)
-		if err != nil {
-			// If summary generation fails, keep old summary
-			summary = existingNote.Summary
-		}
-	} else {
-		// Content didn't change, keep existing summary
-		summary = existingNote.Summary
+	if !llm.ValidStyle(style) {
+		return ErrUnknownStyle
+	}
+	if format == "" {
+		format = existingNote.ContentFormat
+	}
+	if !ValidFormat(format) {
+		return ErrUnknownFormat
 	}
 
-	// STEP 3: Update note fields
+	// STEP 2: Update note fields
+	if content == "" && format != existingNote.ContentFormat {
+		// the stored content is read in the new format from now on
+		content = existingNote.Content
+	}
+	if content != "" {
+		content = sanitizeContent(content, format)
+	}
+	// empty fields were left out of the request and keep their value
+	textChanged := (content != "" && content != existingNote.Content) ||
+		(title != "" && title != existingNote.Title) ||
+		format != existingNote.ContentFormat
+	styleChanged := style != existingNote.SummaryStyle
+	existingNote.SummaryStyle = style
 	if title != "" {
 		existingNote.Title = title
 	}
 	if content != "" {
-		existingNote.Content = content
-	}
-	if summary != "" {
-		existingNote.Summary = summary
+		existingNote.setContent(content, format)
 	}
 	existingNote.UpdatedAt = time.Now()
 
+	// STEP 3: Generate new summary if content or style changed
+	if textChanged || styleChanged {
+		if err := s.summarize(ctx, existingNote); err != nil {
+			// If summary generation fails, keep old summary
+			log.Printf("failed to summarize note %d: %v", noteID, err)
+		}
+	}
+
 	// STEP 4: Save updated note
 	if err := s.repo.Update(existingNote); err != nil {
 		return fmt.Errorf("failed to update note: %w", err)
@@ -196,21 +280,140 @@ func (s *noteService) UpdateNote(noteID uint, userID uint, title string, content
 		}
 
 		// Upload new image
-		if err := s.handleImageUpload(noteID, imageFile); err != nil {
+		if err := s.handleImageUpload(ctx, noteID, imageFile); err != nil {
 			return fmt.Errorf("failed to upload new image: %w", err)
 		}
 	}
+	if textChanged {
+		s.notifySaved(existingNote)
+	}
 	return nil
 }
 
-func (s *noteService) GetOneNote(id uint) (*Note, error) {
+func (s *noteService) GetOneNote(id uint, userID uint) (*Note, error) {
 	if id == 0 {
 		return nil, errors.New("zero value")
 	}
-	res, err := s.repo.GetByID(id)
+	res, err := s.access(id, userID, RoleViewer)
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 
 }
+
+func (s *noteService) RenderNote(noteID, userID uint) (*Note, template.HTML, error) {
+	note, err := s.access(noteID, userID, RoleViewer)
+	if err != nil {
+		return nil, "", err
+	}
+	html, err := note.HTML()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to render note: %w", err)
+	}
+	return note, html, nil
+}
+
+func (s *noteService) SetTags(noteID, userID uint, names []string) ([]Tag, error) {
+	note, err := s.access(noteID, userID, RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	// tags belong to the owner, whoever applies them
+	return s.repo.SetTags(noteID, note.UserID, normalizeTags(names))
+}
+
+func (s *noteService) ListTags(userID uint) ([]Tag, error) {
+	return s.repo.ListTags(userID)
+}
+
+func (s *noteService) ExportNote(noteID, userID uint, format string) (*Note, []byte, error) {
+	if !ValidExportFormat(format) {
+		return nil, nil, ErrUnknownExportFormat
+	}
+	note, err := s.access(noteID, userID, RoleViewer)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := note.Export(format, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export note: %w", err)
+	}
+	return note, data, nil
+}
+
+func (s *noteService) NotesByTag(userID, tagID uint) (*Tag, []Note, error) {
+	tag, err := s.repo.GetTag(userID, tagID)
+	if err != nil {
+		return nil, nil, ErrTagNotFound
+	}
+	notes, err := s.repo.ListByTag(userID, tagID, maxExportNotes+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(notes) > maxExportNotes {
+		return nil, nil, ErrExportTooLarge
+	}
+	return tag, notes, nil
+}
+
+func (s *noteService) Summarize(ctx context.Context, noteID, userID uint, style string) (*Note, error) {
+	note, style, err := s.noteForSummary(noteID, userID, style)
+	if err != nil {
+		return nil, err
+	}
+
+	// an explicit request gets a fresh summary rather than a cached one
+	ctx = llm.ForUser(ctx, userID, llm.FeatureSummary)
+	summary, err := llm.Summarize(ctx, s.llmservice, summaryInput(note.Title, note.ContentText), style)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Summary = summary
+	note.SummaryStyle = style
+	stamp(note)
+	note.UpdatedAt = time.Now()
+	if err := s.repo.Update(note); err != nil {
+		return nil, fmt.Errorf("failed to save summary: %w", err)
+	}
+	return note, nil
+}
+
+func (s *noteService) StreamSummary(ctx context.Context, noteID, userID uint, style string, onChunk func(string) error) (string, error) {
+	note, style, err := s.noteForSummary(noteID, userID, style)
+	if err != nil {
+		return "", err
+	}
+
+	ctx = llm.ForUser(ctx, userID, llm.FeatureSummary)
+	summary, err := llm.StreamSummary(ctx, s.llmservice, summaryInput(note.Title, note.ContentText), style, onChunk)
+	if err != nil {
+		return "", err
+	}
+
+	note.Summary = summary
+	note.SummaryStyle = style
+	stamp(note)
+	note.UpdatedAt = time.Now()
+	if err := s.repo.Update(note); err != nil {
+		return "", fmt.Errorf("failed to save summary: %w", err)
+	}
+	return summary, nil
+}
+
+// noteForSummary loads a note the user can edit and resolves the style to summarize
+// it in, defaulting to the one it was last summarized with.
+func (s *noteService) noteForSummary(noteID, userID uint, style string) (*Note, string, error) {
+	note, err := s.access(noteID, userID, RoleEditor)
+	if err != nil {
+		return nil, "", err
+	}
+	if style == "" {
+		style = note.SummaryStyle
+	}
+	if !llm.ValidStyle(style) {
+		return nil, "", ErrUnknownStyle
+	}
+	return note, style, nil
+}
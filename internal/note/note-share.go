@@ -0,0 +1,172 @@
+package note
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"strings"
+	"time"
+
+	"notemind/internal/mailer"
+)
+
+// Roles a user can have on a note. The owner is the note's UserID; the
+// other roles are granted through shares.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleOwner  = "owner"
+)
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+var (
+	ErrForbidden     = errors.New("you do not have permission to do this with the note")
+	ErrUnknownRole   = errors.New("role must be viewer or editor")
+	ErrShareWithSelf = errors.New("you already own this note")
+	ErrShareNotFound = errors.New("share not found")
+)
+
+// Share grants a user other than the owner access to a note.
+type Share struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	NoteID    uint   `json:"note_id"`
+	UserID    uint   `json:"user_id"`
+	Role      string `json:"role"`
+	InvitedBy *uint  `json:"invited_by"`
+	// Email and Name are those of the user the note is shared with, read
+	// along with the share.
+	Email     string    `json:"email" gorm:"->"`
+	Name      string    `json:"name" gorm:"->"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Share) TableName() string {
+	return "note_shares"
+}
+
+// ShareUser is the account a note is shared with.
+type ShareUser struct {
+	ID    uint
+	Name  string
+	Email string
+}
+
+// access loads a note userID wants at least role on, setting its Role to
+// the user's. Notes the user cannot see at all are reported as not found.
+func (s *noteService) access(noteID, userID uint, role string) (*Note, error) {
+	note, err := s.repo.GetByID(noteID)
+	if err != nil {
+		return nil, ErrNoteNotFound
+	}
+	note.Role = RoleOwner
+	if note.UserID != userID {
+		share, err := s.repo.GetShare(noteID, userID)
+		if err != nil {
+			return nil, ErrNoteNotFound
+		}
+		note.Role = share.Role
+	}
+	if roleRank[note.Role] < roleRank[role] {
+		return nil, ErrForbidden
+	}
+	return note, nil
+}
+
+func (s *noteService) ShareNote(noteID, ownerID uint, email, role string) error {
+	if role != RoleViewer && role != RoleEditor {
+		return ErrUnknownRole
+	}
+	note, err := s.access(noteID, ownerID, RoleOwner)
+	if err != nil {
+		return err
+	}
+	user, err := s.repo.FindUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		// answered like a share, so that sharing does not tell which
+		// emails have an account
+		return nil
+	}
+	if user.ID == note.UserID {
+		return ErrShareWithSelf
+	}
+
+	now := time.Now().UTC()
+	share := &Share{
+		NoteID:    noteID,
+		UserID:    user.ID,
+		Role:      role,
+		InvitedBy: &ownerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.repo.SaveShare(share); err != nil {
+		return fmt.Errorf("failed to share note: %w", err)
+	}
+
+	go s.sendInvitation(note, user, role)
+	return nil
+}
+
+func (s *noteService) ListShares(noteID, ownerID uint) ([]Share, error) {
+	if _, err := s.access(noteID, ownerID, RoleOwner); err != nil {
+		return nil, err
+	}
+	return s.repo.ListShares(noteID)
+}
+
+func (s *noteService) RemoveShare(noteID, userID, sharedWithID uint) error {
+	// users may leave a note shared with them, only the owner removes others
+	role := RoleOwner
+	if sharedWithID == userID {
+		role = RoleViewer
+	}
+	if _, err := s.access(noteID, userID, role); err != nil {
+		return err
+	}
+	removed, err := s.repo.DeleteShare(noteID, sharedWithID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrShareNotFound
+	}
+	return nil
+}
+
+func (s *noteService) SharedWithMe(userID uint) ([]Note, error) {
+	return s.repo.ListSharedWith(userID)
+}
+
+// sendInvitation tells a user a note was shared with them. The share
+// stands whether or not the email goes out.
+func (s *noteService) sendInvitation(note *Note, user *ShareUser, role string) {
+	if s.mail == nil {
+		return
+	}
+	title := note.Title
+	if title == "" {
+		title = "Untitled note"
+	}
+	access := "view"
+	if role == RoleEditor {
+		access = "view and edit"
+	}
+
+	text := fmt.Sprintf("Hello %s,\n\nA note, \"%s\", was shared with you on NoteMind. You can now %s it; it is listed under notes shared with you.", user.Name, title, access)
+	html := fmt.Sprintf(`<p>Hello %s,</p><p>A note, <strong>%s</strong>, was shared with you on NoteMind. You can now %s it; it is listed under notes shared with you.</p>`,
+		template.HTMLEscapeString(user.Name), template.HTMLEscapeString(title), access)
+
+	err := s.mail.Send(context.Background(), mailer.Message{
+		To:      mailer.Address{Email: user.Email, Name: user.Name},
+		Subject: "A note was shared with you",
+		Text:    text,
+		HTML:    html,
+	})
+	if err != nil {
+		log.Printf("note: failed to send share invitation for note %d to user %d: %v", note.ID, user.ID, err)
+	}
+}
@@ -0,0 +1,54 @@
+package note
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// shareRepo adds the accounts notes can be shared with to fakeRepo.
+type shareRepo struct {
+	*fakeRepo
+	users  []ShareUser
+	shares []Share
+}
+
+func (r *shareRepo) FindUserByEmail(email string) (*ShareUser, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *shareRepo) SaveShare(share *Share) error {
+	r.shares = append(r.shares, *share)
+	return nil
+}
+
+func TestShareNoteDoesNotRevealAccounts(t *testing.T) {
+	repo := &shareRepo{
+		fakeRepo: newFakeRepo(testNote()),
+		users:    []ShareUser{{ID: 7, Email: "owner@example.com"}, {ID: 8, Email: "ada@example.com"}},
+	}
+	service := &noteService{repo: repo}
+
+	if err := service.ShareNote(1, 7, "nobody@example.com", RoleViewer); err != nil {
+		t.Errorf("ShareNote with an unknown email = %v, want it answered like a share", err)
+	}
+	if len(repo.shares) != 0 {
+		t.Errorf("saved %+v for an unknown email, want nothing", repo.shares)
+	}
+
+	if err := service.ShareNote(1, 7, " ADA@example.com ", RoleEditor); err != nil {
+		t.Fatalf("ShareNote: %v", err)
+	}
+	if len(repo.shares) != 1 || repo.shares[0].UserID != 8 || repo.shares[0].Role != RoleEditor {
+		t.Errorf("saved %+v, want an editor share with user 8", repo.shares)
+	}
+
+	if err := service.ShareNote(1, 7, "owner@example.com", RoleViewer); !errors.Is(err, ErrShareWithSelf) {
+		t.Errorf("ShareNote with the owner's email = %v, want ErrShareWithSelf", err)
+	}
+}
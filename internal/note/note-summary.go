@@ -0,0 +1,142 @@
+package note
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"notemind/internal/llm"
+)
+
+// ResummarizeReport describes what a run of the stale summary job did.
+type ResummarizeReport struct {
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	Total        int       `json:"total"`
+	Resummarized int       `json:"resummarized"`
+	// Pending are the notes that could not be summarized for now, their
+	// owner being out of quota or the model unavailable.
+	Pending int `json:"pending"`
+	Blocked int `json:"blocked"`
+	Failed  int `json:"failed"`
+}
+
+// summaryInput is the text a note's summary is generated from.
+func summaryInput(title, content string) string {
+	return fmt.Sprintf("Title: %s\nContent: %s", title, content)
+}
+
+// contentHash identifies a summary request: the same text in the same style
+// gets the same summary from the same prompt version.
+func contentHash(noteText, style string) string {
+	sum := sha256.Sum256([]byte(style + "\x00" + noteText))
+	return hex.EncodeToString(sum[:])
+}
+
+// summarize writes the summary of note in its style, reusing the summary of
+// another of the user's notes with identical text when the prompt has not
+// changed since. When the summary could not be written for the time being,
+// the user being out of quota or the model being unavailable, the note is
+// marked pending. Content the model refuses to summarize leaves the note
+// blocked without a summary. On other errors the note is left as it was.
+func (s *noteService) summarize(ctx context.Context, note *Note) error {
+	noteText := summaryInput(note.Title, note.ContentText)
+	hash := contentHash(noteText, note.SummaryStyle)
+	version := llm.PromptVersion(note.SummaryStyle)
+
+	summary, err := s.repo.FindSummary(note.UserID, hash, version)
+	if err != nil {
+		log.Printf("note: summary cache lookup failed: %v", err)
+	}
+	if summary == "" {
+		ctx = llm.ForUser(ctx, note.UserID, llm.FeatureSummary)
+		summary, err = llm.Summarize(ctx, s.llmservice, noteText, note.SummaryStyle)
+		if llm.IsTemporary(err) {
+			note.SummaryStatus = SummaryPending
+			return err
+		}
+		if errors.Is(err, llm.ErrBlocked) {
+			log.Printf("note: summary of note %d blocked: %v", note.ID, err)
+			note.Summary = ""
+			note.SummaryStatus = SummaryBlocked
+			note.ContentHash = hash
+			note.PromptVersion = version
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	note.Summary = summary
+	note.SummaryStatus = SummaryReady
+	note.ContentHash = hash
+	note.PromptVersion = version
+	return nil
+}
+
+// stamp records what a freshly generated summary of note was written from.
+func stamp(note *Note) {
+	note.SummaryStatus = SummaryReady
+	note.ContentHash = contentHash(summaryInput(note.Title, note.ContentText), note.SummaryStyle)
+	note.PromptVersion = llm.PromptVersion(note.SummaryStyle)
+}
+
+func (s *noteService) ResummarizeStale(ctx context.Context, limit int) (*ResummarizeReport, error) {
+	notes, err := s.repo.ListStale(llm.PromptVersions(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stale notes: %w", err)
+	}
+	return s.resummarize(ctx, "stale", notes)
+}
+
+func (s *noteService) SummarizePending(ctx context.Context, limit int) (*ResummarizeReport, error) {
+	notes, err := s.repo.ListPending(limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending notes: %w", err)
+	}
+	return s.resummarize(ctx, "pending", notes)
+}
+
+func (s *noteService) resummarize(ctx context.Context, kind string, notes []Note) (*ResummarizeReport, error) {
+	report := &ResummarizeReport{StartedAt: time.Now().UTC()}
+	for i := range notes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		note := &notes[i]
+		report.Total++
+
+		err := s.summarize(ctx, note)
+		switch {
+		case err != nil && note.SummaryStatus == SummaryPending:
+			report.Pending++
+		case err != nil:
+			log.Printf("note: failed to summarize note %d: %v", note.ID, err)
+			report.Failed++
+			continue
+		}
+		if err := s.repo.UpdateSummary(note); err != nil {
+			log.Printf("note: failed to save summary of note %d: %v", note.ID, err)
+			report.Failed++
+			continue
+		}
+		switch note.SummaryStatus {
+		case SummaryReady:
+			report.Resummarized++
+		case SummaryBlocked:
+			report.Blocked++
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	if report.Total > 0 {
+		log.Printf("note: summarized %d of %d %s notes, %d pending, %d failed",
+			report.Resummarized, report.Total, kind, report.Pending, report.Failed)
+	}
+	return report, nil
+}
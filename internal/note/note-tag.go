@@ -0,0 +1,53 @@
+package note
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxTagLength = 50
+	maxNoteTags  = 20
+)
+
+// Tag is a label a user files notes under. Names are unique per user,
+// ignoring case.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// normalizeTags trims names, drops empty and duplicate ones, ignoring case,
+// and keeps at most maxNoteTags.
+func normalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.Join(strings.Fields(name), " ")
+		if utf8.RuneCountInString(name) > maxTagLength {
+			name = strings.TrimSpace(string([]rune(name)[:maxTagLength]))
+		}
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, name)
+		if len(tags) == maxNoteTags {
+			break
+		}
+	}
+	return tags
+}
+
+// TagNames lists the names of the note's tags.
+func (n *Note) TagNames() []string {
+	names := make([]string, len(n.Tags))
+	for i, tag := range n.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
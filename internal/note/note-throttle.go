@@ -0,0 +1,69 @@
+package note
+
+import (
+	"sync"
+	"time"
+)
+
+// attemptLimiter counts attempts per key in fixed windows, so that guessing
+// a link password, each guess costing a bcrypt comparison, is slowed down.
+// An attempt is counted before it is made and refunded when it succeeds,
+// so that parallel guesses cannot get past the limit. Counts live in
+// memory: each replica throttles on its own.
+type attemptLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	attempts  map[string]*attemptWindow
+	lastPrune time.Time
+}
+
+type attemptWindow struct {
+	start time.Time
+	count int
+}
+
+func newAttemptLimiter(limit int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{limit: limit, window: window, attempts: map[string]*attemptWindow{}}
+}
+
+// reserve counts an attempt of key, reporting false without counting it
+// when key has used up its attempts.
+func (l *attemptLimiter) reserve(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+	w, ok := l.attempts[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &attemptWindow{start: now}
+		l.attempts[key] = w
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	return true
+}
+
+// refund gives back an attempt of key that succeeded.
+func (l *attemptLimiter) refund(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w, ok := l.attempts[key]; ok && w.count > 0 {
+		w.count--
+	}
+}
+
+// prune forgets windows that are over, at most once a window.
+func (l *attemptLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	l.lastPrune = now
+	for key, w := range l.attempts {
+		if now.Sub(w.start) >= l.window {
+			delete(l.attempts, key)
+		}
+	}
+}
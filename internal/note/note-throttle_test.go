@@ -0,0 +1,49 @@
+package note
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAttemptLimiterCapsParallelAttempts(t *testing.T) {
+	limiter := newAttemptLimiter(5, time.Minute)
+	now := time.Now()
+
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if limiter.reserve("link", now) {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := allowed.Load(); n != 5 {
+		t.Errorf("%d parallel attempts allowed, want 5", n)
+	}
+}
+
+func TestAttemptLimiterRefundsSuccesses(t *testing.T) {
+	limiter := newAttemptLimiter(2, time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		if !limiter.reserve("link", now) {
+			t.Fatalf("attempt %d refused, successful attempts should not count", i)
+		}
+		limiter.refund("link")
+	}
+	limiter.reserve("link", now)
+	limiter.reserve("link", now)
+	if limiter.reserve("link", now) {
+		t.Error("third failed attempt allowed, want the limit of 2 kept")
+	}
+	if !limiter.reserve("link", now.Add(time.Minute)) {
+		t.Error("attempt refused after the window ended")
+	}
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skipped are elements whose content is never text a person reads.
+var skipped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Head:     true,
+	atom.Template: true,
+	atom.Noscript: true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Svg:      true,
+}
+
+// blocks are elements that start on a line of their own.
+var blocks = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Hr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Ul: true, atom.Ol: true, atom.Li: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Table: true, atom.Tr: true, atom.Blockquote: true, atom.Pre: true,
+	atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+	atom.Aside: true, atom.Nav: true, atom.Figure: true, atom.Figcaption: true,
+}
+
+// lineItems are blocks that only need a new line before them.
+var lineItems = map[atom.Atom]bool{
+	atom.Li: true, atom.Dt: true, atom.Dd: true, atom.Tr: true, atom.Br: true,
+}
+
+var (
+	spaces     = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+)
+
+// HTMLText turns HTML into the plain text a reader would see: tags are
+// dropped, entities decoded, scripts, styles and comments left out, and
+// block elements put on lines of their own.
+func HTMLText(src string) string {
+	z := html.NewTokenizer(strings.NewReader(src))
+	var buf strings.Builder
+	skipDepth := 0
+	pre := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// io.EOF, or markup too broken to go on with
+			return tidyText(buf.String())
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			text := string(z.Text())
+			if pre == 0 {
+				text = spaces.ReplaceAllString(text, " ")
+				if afterSpace(&buf) {
+					text = strings.TrimLeft(text, " ")
+				}
+			}
+			buf.WriteString(text)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if skipped[a] {
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			if a == atom.Pre {
+				pre++
+			}
+			if blocks[a] {
+				buf.WriteString("\n")
+			}
+			if a == atom.Li {
+				buf.WriteString("- ")
+			}
+			if (a == atom.Td || a == atom.Th) && !afterSpace(&buf) {
+				buf.WriteString(" ")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			a := atom.Lookup(name)
+			if skipped[a] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if a == atom.Pre && pre > 0 {
+				pre--
+			}
+			if blocks[a] && !lineItems[a] {
+				buf.WriteString("\n")
+			}
+		}
+	}
+}
+
+// afterSpace reports whether the text so far ends a word.
+func afterSpace(buf *strings.Builder) bool {
+	out := buf.String()
+	return out == "" || strings.HasSuffix(out, " ") || strings.HasSuffix(out, "\n")
+}
+
+func tidyText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
@@ -0,0 +1,69 @@
+package render
+
+import (
+	"bytes"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	// policy allows the formatting markdown produces and nothing that can run
+	// script or load remote content on its own: images keep their source
+	// only when it is the media store note images are uploaded to.
+	policy = bluemonday.UGCPolicy().RewriteSrc(keepMediaSrc)
+
+	paragraphs = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+)
+
+// mediaHost serves the images uploaded to the media store.
+const mediaHost = "res.cloudinary.com"
+
+// keepMediaSrc empties the source of images and other embedded media served
+// from anywhere but the media store, so that rendering a page cannot make
+// the reader's browser fetch, say, a tracking pixel.
+func keepMediaSrc(u *url.URL) {
+	if u.Scheme != "https" || u.Host != mediaHost {
+		*u = url.URL{}
+	}
+}
+
+// Markdown renders untrusted markdown, such as LLM output, to sanitized HTML
+// that is safe to embed in a page or an email.
+func Markdown(src string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return template.HTML(policy.SanitizeBytes(buf.Bytes())), nil
+}
+
+// SanitizeHTML strips untrusted HTML, such as a note from a rich-text
+// editor, down to the same allowlist of formatting markup.
+func SanitizeHTML(src string) string {
+	return policy.Sanitize(src)
+}
+
+// PlainHTML renders plain text as HTML paragraphs, keeping its line breaks.
+func PlainHTML(src string) template.HTML {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, para := range paragraphs.Split(strings.TrimSpace(src), -1) {
+		if para == "" {
+			continue
+		}
+		lines := strings.Split(para, "\n")
+		for i, line := range lines {
+			lines[i] = template.HTMLEscapeString(line)
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>\n")
+	}
+	return template.HTML(b.String())
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// MarkdownText turns markdown into readable plain text for the text part of
+// an email: emphasis markers and heading hashes are dropped, list items keep
+// their marker and links keep their target in parentheses.
+func MarkdownText(src string) string {
+	source := []byte(src)
+	doc := markdown.Parser().Parse(text.NewReader(source))
+
+	var buf bytes.Buffer
+	writeBlocks(&buf, doc, source, "")
+	return strings.TrimSpace(buf.String())
+}
+
+func writeBlocks(buf *bytes.Buffer, parent ast.Node, source []byte, indent string) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.List:
+			number := node.Start
+			for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+				marker := "- "
+				if node.IsOrdered() {
+					marker = strconv.Itoa(number) + ". "
+					number++
+				}
+				var inner bytes.Buffer
+				writeBlocks(&inner, item, source, "")
+				first := true
+				for _, line := range strings.Split(strings.TrimSpace(inner.String()), "\n") {
+					if line == "" {
+						continue
+					}
+					prefix := indent + strings.Repeat(" ", len(marker))
+					if first {
+						prefix = indent + marker
+						first = false
+					}
+					buf.WriteString(prefix + line + "\n")
+				}
+			}
+			buf.WriteString("\n")
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				seg := lines.At(i)
+				buf.WriteString(indent + "    " + string(seg.Value(source)))
+			}
+			buf.WriteString("\n")
+		case *ast.ThematicBreak:
+			buf.WriteString(indent + "----\n\n")
+		case *ast.Blockquote:
+			var inner bytes.Buffer
+			writeBlocks(&inner, n, source, "")
+			for _, line := range strings.Split(strings.TrimSpace(inner.String()), "\n") {
+				buf.WriteString(indent + "> " + line + "\n")
+			}
+			buf.WriteString("\n")
+		default:
+			if n.Type() == ast.TypeBlock && n.HasChildren() && n.FirstChild().Type() == ast.TypeBlock {
+				writeBlocks(buf, n, source, indent)
+				continue
+			}
+			buf.WriteString(indent)
+			writeInline(buf, n, source)
+			buf.WriteString("\n\n")
+		}
+	}
+}
+
+func writeInline(buf *bytes.Buffer, parent ast.Node, source []byte) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch node := n.(type) {
+		case *ast.Text:
+			buf.Write(node.Segment.Value(source))
+			if node.HardLineBreak() || node.SoftLineBreak() {
+				buf.WriteString("\n")
+			}
+		case *ast.String:
+			buf.Write(node.Value)
+		case *ast.CodeSpan:
+			writeInline(buf, n, source)
+		case *ast.Link:
+			var label bytes.Buffer
+			writeInline(&label, n, source)
+			buf.Write(label.Bytes())
+			if dest := string(node.Destination); dest != "" && dest != label.String() {
+				buf.WriteString(" (" + dest + ")")
+			}
+		case *ast.AutoLink:
+			buf.Write(node.URL(source))
+		case *ast.RawHTML:
+			// raw HTML is not rendered in the HTML part either
+		default:
+			writeInline(buf, n, source)
+		}
+	}
+}
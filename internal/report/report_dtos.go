@@ -0,0 +1,11 @@
+package report
+
+// CreateReportRequest asks for the retrospective of the period containing
+// Date (YYYY-MM-DD, in the user's timezone). Without a date it covers the
+// last complete week or month.
+type CreateReportRequest struct {
+	Period string `json:"period" binding:"required,oneof=weekly monthly"`
+	Date   string `json:"date"`
+	// Email sends the report to the user once it is ready.
+	Email bool `json:"email"`
+}
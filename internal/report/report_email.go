@@ -0,0 +1,59 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"notemind/internal/auth"
+	"notemind/internal/mailer"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var (
+	reportHTML = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/report.html.tmpl"))
+	reportText = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/report.txt.tmpl"))
+)
+
+type reportEmail struct {
+	Subject     string
+	Name        string
+	PeriodTitle string
+	PeriodLower string
+	Range       string
+	Retro       *Retrospective
+}
+
+func buildReportEmail(user *auth.User, report *Report) (*mailer.Message, error) {
+	data := reportEmail{
+		Subject:     "Your Weekly Retrospective 🔭",
+		Name:        user.Name,
+		PeriodTitle: "Weekly",
+		PeriodLower: "weekly",
+		Range:       report.PeriodStart.Format("Jan 2") + " – " + report.PeriodEnd.Format("Jan 2, 2006"),
+		Retro:       report.Retrospective,
+	}
+	if report.Period == PeriodMonthly {
+		data.Subject = "Your Monthly Retrospective 🔭"
+		data.PeriodTitle = "Monthly"
+		data.PeriodLower = "monthly"
+		data.Range = report.PeriodStart.Format("January 2006")
+	}
+
+	var html, text bytes.Buffer
+	if err := reportHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+	if err := reportText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	return &mailer.Message{
+		To:      mailer.Address{Email: user.Email, Name: user.Name},
+		Subject: data.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type ReportHandler struct {
+	reportService ReportService
+}
+
+func NewReportHandler(reportService ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportService}
+}
+
+func (h *ReportHandler) CreateReport(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateReportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, created, err := h.reportService.Generate(user.UserID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidDate) || errors.Is(err, ErrFuturePeriod) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		return
+	}
+
+	if !created {
+		ctx.JSON(http.StatusOK, gin.H{"report": report})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Report is being generated",
+		"report":  report,
+	})
+}
+
+func (h *ReportHandler) ListReports(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	period := ctx.Query("period")
+	if period != "" && period != PeriodWeekly && period != PeriodMonthly {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "period must be weekly or monthly"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultListLimit)))
+	if err != nil || limit < 1 || limit > maxListLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+		return
+	}
+
+	reports, total, err := h.reportService.List(user.UserID, period, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"reports": reports, "total": total})
+}
+
+func (h *ReportHandler) GetReport(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	reportID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || reportID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	report, err := h.reportService.Get(user.UserID, uint(reportID))
+	if err != nil {
+		if errors.Is(err, ErrReportNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+func (h *ReportHandler) DeleteReport(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	reportID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil || reportID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	if err := h.reportService.Delete(user.UserID, uint(reportID)); err != nil {
+		if errors.Is(err, ErrReportNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Report deleted"})
+}
@@ -0,0 +1,81 @@
+package report
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+)
+
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+// Report is a retrospective over one week or month of a user's notes.
+// PeriodStart and PeriodEnd are local dates in the user's timezone, the end
+// being inclusive.
+type Report struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	UserID         uint           `json:"user_id"`
+	Period         string         `json:"period"`
+	PeriodStart    time.Time      `json:"period_start" gorm:"type:date"`
+	PeriodEnd      time.Time      `json:"period_end" gorm:"type:date"`
+	Status         string         `json:"status"`
+	NoteCount      int            `json:"note_count"`
+	Retrospective  *Retrospective `json:"retrospective,omitempty" gorm:"type:jsonb"`
+	Error          string         `json:"error,omitempty"`
+	EmailRequested bool           `json:"email_requested"`
+	EmailedAt      *time.Time     `json:"emailed_at"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Retrospective is what the LLM found in the period's notes.
+type Retrospective struct {
+	Overview        string   `json:"overview"`
+	Themes          []Theme  `json:"themes"`
+	RecurringTopics []string `json:"recurring_topics"`
+	OpenQuestions   []string `json:"open_questions"`
+}
+
+// Theme groups notes that are about the same thing.
+type Theme struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	NoteIDs []uint `json:"note_ids"`
+}
+
+func (r Retrospective) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *Retrospective) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported retrospective value")
+	}
+	return json.Unmarshal(data, r)
+}
+
+// PeriodNote is the slice of a note a retrospective is generated from.
+type PeriodNote struct {
+	ID        uint
+	Title     string
+	Summary   string
+	Content   string
+	CreatedAt time.Time
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"notemind/internal/llm"
+)
+
+// maxNoteChars bounds how much of a note without a summary goes into the prompt.
+const maxNoteChars = 600
+
+func buildPrompt(period string, notes []PeriodNote) string {
+	var b strings.Builder
+	for _, n := range notes {
+		text := n.Summary
+		if strings.TrimSpace(text) == "" {
+			text = truncate(n.Content, maxNoteChars)
+		}
+		fmt.Fprintf(&b, "[note %d] %s (%s)\n%s\n\n", n.ID, n.Title, n.CreatedAt.Format("Mon Jan 2"), strings.TrimSpace(text))
+	}
+
+	return fmt.Sprintf(`You are a personal learning assistant writing a %s retrospective of a user's notes.
+Group the notes into a few themes, point out topics that keep coming back, and list questions the notes raise but do not answer.
+Only use what is in the notes. Refer to notes by their number.
+
+Answer with JSON only, no markdown fences, in exactly this shape:
+{
+  "overview": "2-3 sentences about the period",
+  "themes": [{"title": "short title", "summary": "1-2 sentences", "note_ids": [1, 2]}],
+  "recurring_topics": ["topic"],
+  "open_questions": ["question"]
+}
+
+%s
+
+Notes:
+%s`, period, llm.UntrustedNotice, llm.Untrusted("notes", b.String()))
+}
+
+// parseRetrospective reads the LLM answer. Note ids that are not part of
+// the period are dropped so a report never links to someone else's notes.
+func parseRetrospective(answer string, notes []PeriodNote) (*Retrospective, error) {
+	answer = strings.TrimSpace(answer)
+	answer = strings.TrimPrefix(answer, "```json")
+	answer = strings.TrimPrefix(answer, "```")
+	answer = strings.TrimSuffix(answer, "```")
+	start, end := strings.Index(answer, "{"), strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("LLM answer is not JSON")
+	}
+
+	var retro Retrospective
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &retro); err != nil {
+		return nil, fmt.Errorf("LLM answer is not valid JSON: %w", err)
+	}
+
+	known := make(map[uint]bool, len(notes))
+	for _, n := range notes {
+		known[n.ID] = true
+	}
+	for i := range retro.Themes {
+		ids := retro.Themes[i].NoteIDs[:0]
+		for _, id := range retro.Themes[i].NoteIDs {
+			if known[id] {
+				ids = append(ids, id)
+			}
+		}
+		retro.Themes[i].NoteIDs = ids
+	}
+	return &retro, nil
+}
+
+func truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max]) + "…"
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"time"
+
+	"notemind/internal/auth"
+
+	"gorm.io/gorm"
+)
+
+type ReportRepo interface {
+	GetUser(userID uint) (*auth.User, error)
+	Create(report *Report) error
+	Update(report *Report) error
+	GetByID(userID, reportID uint) (*Report, error)
+	GetForPeriod(userID uint, period string, start time.Time) (*Report, error)
+	// List returns the user's reports, newest period first. An empty period
+	// lists both kinds.
+	List(userID uint, period string, limit, offset int) ([]Report, int64, error)
+	Delete(userID, reportID uint) error
+	// NotesBetween returns up to limit notes created in [from, to), oldest first.
+	NotesBetween(userID uint, from, to time.Time, limit int) ([]PeriodNote, error)
+}
+
+type reportRepo struct {
+	db *gorm.DB
+}
+
+func NewReportRepo(db *gorm.DB) ReportRepo {
+	return &reportRepo{db: db}
+}
+
+func (r *reportRepo) GetUser(userID uint) (*auth.User, error) {
+	var user auth.User
+	if err := r.db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *reportRepo) Create(report *Report) error {
+	return r.db.Create(report).Error
+}
+
+func (r *reportRepo) Update(report *Report) error {
+	return r.db.Save(report).Error
+}
+
+func (r *reportRepo) GetByID(userID, reportID uint) (*Report, error) {
+	var report Report
+	err := r.db.Where("id = ? AND user_id = ?", reportID, userID).First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *reportRepo) GetForPeriod(userID uint, period string, start time.Time) (*Report, error) {
+	var report Report
+	err := r.db.Where("user_id = ? AND period = ? AND period_start = ?", userID, period, start.Format("2006-01-02")).
+		First(&report).Error
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *reportRepo) List(userID uint, period string, limit, offset int) ([]Report, int64, error) {
+	query := r.db.Model(&Report{}).Where("user_id = ?", userID)
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var reports []Report
+	err := query.Order("period_start DESC, id DESC").Limit(limit).Offset(offset).Find(&reports).Error
+	return reports, total, err
+}
+
+func (r *reportRepo) Delete(userID, reportID uint) error {
+	res := r.db.Where("id = ? AND user_id = ?", reportID, userID).Delete(&Report{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *reportRepo) NotesBetween(userID uint, from, to time.Time, limit int) ([]PeriodNote, error) {
+	var notes []PeriodNote
+	err := r.db.Table("notes").
+		Select("id, title, summary, content_text AS content, created_at").
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from.UTC(), to.UTC()).
+		Order("created_at").
+		Limit(limit).
+		Scan(&notes).Error
+	return notes, err
+}
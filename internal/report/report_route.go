@@ -0,0 +1,18 @@
+package report
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, reportHandler *ReportHandler, authMiddleware gin.HandlerFunc) {
+	reports := router.Group("/api/v1/reports")
+	reports.Use(authMiddleware, token.RequireScope(token.ScopeNotesRead))
+	{
+		reports.POST("", token.RequireScope(token.ScopeNotesWrite), reportHandler.CreateReport)
+		reports.GET("", reportHandler.ListReports)
+		reports.GET("/:id", reportHandler.GetReport)
+		reports.DELETE("/:id", token.RequireScope(token.ScopeNotesWrite), reportHandler.DeleteReport)
+	}
+}
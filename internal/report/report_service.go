@@ -0,0 +1,216 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"notemind/internal/auth"
+	"notemind/internal/llm"
+	"notemind/internal/mailer"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxReportNotes bounds the prompt for very busy periods.
+	maxReportNotes = 200
+	// staleAfter is when a pending report is assumed lost to a restart.
+	staleAfter      = 15 * time.Minute
+	generateTimeout = 2 * time.Minute
+	dateLayout      = "2006-01-02"
+)
+
+var (
+	ErrReportNotFound = errors.New("report not found")
+	ErrInvalidDate    = errors.New("date must be formatted as YYYY-MM-DD")
+	ErrFuturePeriod   = errors.New("the period has not started yet")
+)
+
+type ReportService interface {
+	// Generate starts building the retrospective for the requested period in
+	// the background. An existing report for the same period is returned
+	// instead unless it failed; created tells the two apart.
+	Generate(userID uint, req CreateReportRequest) (report *Report, created bool, err error)
+	List(userID uint, period string, limit, offset int) ([]Report, int64, error)
+	Get(userID, reportID uint) (*Report, error)
+	Delete(userID, reportID uint) error
+}
+
+type reportService struct {
+	repo ReportRepo
+	llm  llm.Client
+	mail mailer.Mailer
+}
+
+func NewReportService(repo ReportRepo, llm llm.Client, mail mailer.Mailer) ReportService {
+	return &reportService{repo: repo, llm: llm, mail: mail}
+}
+
+func (s *reportService) Generate(userID uint, req CreateReportRequest) (*Report, bool, error) {
+	user, err := s.repo.GetUser(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	loc := time.UTC
+	if user.Timezone != "" {
+		if l, err := time.LoadLocation(user.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	today := startOfDay(time.Now().In(loc))
+	anchor := lastCompletePeriod(req.Period, today)
+	if req.Date != "" {
+		anchor, err = time.ParseInLocation(dateLayout, req.Date, loc)
+		if err != nil {
+			return nil, false, ErrInvalidDate
+		}
+	}
+	start, end := periodBounds(req.Period, anchor)
+	if start.After(today) {
+		return nil, false, ErrFuturePeriod
+	}
+
+	report, err := s.repo.GetForPeriod(userID, req.Period, start)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		report = &Report{
+			UserID:      userID,
+			Period:      req.Period,
+			PeriodStart: dateOnly(start),
+			PeriodEnd:   dateOnly(end.AddDate(0, 0, -1)),
+		}
+	case err != nil:
+		return nil, false, err
+	case report.Status == StatusFailed,
+		report.Status == StatusPending && time.Since(report.UpdatedAt) > staleAfter:
+		// retried below
+	default:
+		return report, false, nil
+	}
+
+	report.Status = StatusPending
+	report.Error = ""
+	report.Retrospective = nil
+	report.EmailRequested = req.Email
+	if report.ID == 0 {
+		err = s.repo.Create(report)
+	} else {
+		err = s.repo.Update(report)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	go s.build(user, report, start, end)
+
+	return report, true, nil
+}
+
+func (s *reportService) build(user *auth.User, report *Report, from, to time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), generateTimeout)
+	defer cancel()
+
+	err := s.generate(ctx, report, from, to)
+	if err != nil {
+		log.Printf("report %d failed: %v", report.ID, err)
+		report.Status = StatusFailed
+		report.Error = "report generation failed, please try again later"
+	} else {
+		report.Status = StatusReady
+	}
+	if err := s.repo.Update(report); err != nil {
+		log.Printf("report %d: failed to save result: %v", report.ID, err)
+		return
+	}
+
+	if err == nil && report.EmailRequested && report.NoteCount > 0 {
+		s.email(ctx, user, report)
+	}
+}
+
+func (s *reportService) generate(ctx context.Context, report *Report, from, to time.Time) error {
+	notes, err := s.repo.NotesBetween(report.UserID, from, to, maxReportNotes)
+	if err != nil {
+		return fmt.Errorf("failed to load notes: %w", err)
+	}
+	report.NoteCount = len(notes)
+	if len(notes) == 0 {
+		report.Retrospective = &Retrospective{Overview: "You did not write any notes in this period."}
+		return nil
+	}
+
+	answer, err := s.llm.Generate(llm.ForUser(ctx, report.UserID, llm.FeatureReport), buildPrompt(report.Period, notes))
+	if err != nil {
+		return err
+	}
+	report.Retrospective, err = parseRetrospective(answer, notes)
+	return err
+}
+
+func (s *reportService) email(ctx context.Context, user *auth.User, report *Report) {
+	msg, err := buildReportEmail(user, report)
+	if err == nil {
+		err = s.mail.Send(ctx, *msg)
+	}
+	if err != nil {
+		log.Printf("report %d: failed to send email: %v", report.ID, err)
+		return
+	}
+	now := time.Now().UTC()
+	report.EmailedAt = &now
+	if err := s.repo.Update(report); err != nil {
+		log.Printf("report %d: failed to record email: %v", report.ID, err)
+	}
+}
+
+func (s *reportService) List(userID uint, period string, limit, offset int) ([]Report, int64, error) {
+	return s.repo.List(userID, period, limit, offset)
+}
+
+func (s *reportService) Get(userID, reportID uint) (*Report, error) {
+	report, err := s.repo.GetByID(userID, reportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReportNotFound
+	}
+	return report, err
+}
+
+func (s *reportService) Delete(userID, reportID uint) error {
+	err := s.repo.Delete(userID, reportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrReportNotFound
+	}
+	return err
+}
+
+// periodBounds returns the local period containing day as [start, end).
+// Weeks start on Monday.
+func periodBounds(period string, day time.Time) (time.Time, time.Time) {
+	day = startOfDay(day)
+	if period == PeriodMonthly {
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 1, 0)
+	}
+	start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	return start, start.AddDate(0, 0, 7)
+}
+
+// lastCompletePeriod returns a day inside the last period that has ended.
+func lastCompletePeriod(period string, today time.Time) time.Time {
+	start, _ := periodBounds(period, today)
+	return start.AddDate(0, 0, -1)
+}
+
+// dateOnly keeps the calendar date of a local time at UTC midnight, so it is
+// stored in a date column unchanged whatever the session timezone.
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,18 @@
+package review
+
+import "time"
+
+type GradeRequest struct {
+	// Grade is 0-5 as in SM-2: 0-2 forgotten, 3 hard, 4 good, 5 easy.
+	Grade *int `json:"grade" binding:"required"`
+}
+
+// DueCard is a card waiting for review, with the note it came from.
+type DueCard struct {
+	ID        uint      `json:"id"`
+	NoteID    uint      `json:"note_id"`
+	NoteTitle string    `json:"note_title"`
+	Question  string    `json:"question"`
+	Answer    string    `json:"answer"`
+	DueAt     time.Time `json:"due_at"`
+}
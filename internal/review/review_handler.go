@@ -0,0 +1,135 @@
+package review
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"notemind/internal/llm"
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDueLimit = 20
+	maxDueLimit     = 100
+)
+
+type ReviewHandler struct {
+	reviewService ReviewService
+}
+
+func NewReviewHandler(reviewService ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+func (h *ReviewHandler) ListDue(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultDueLimit)))
+	if err != nil || limit < 1 || limit > maxDueLimit {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	cards, total, err := h.reviewService.Due(user.UserID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"cards": cards, "total_due": total})
+}
+
+func (h *ReviewHandler) GradeCard(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	cardID, err := strconv.ParseUint(ctx.Param("cardId"), 10, 32)
+	if err != nil || cardID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card ID"})
+		return
+	}
+	var req GradeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	card, err := h.reviewService.Grade(user.UserID, uint(cardID), *req.Grade)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidGrade):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrCardNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"card": card})
+}
+
+func (h *ReviewHandler) ListNoteCards(ctx *gin.Context) {
+	user, noteID, ok := h.noteRequest(ctx)
+	if !ok {
+		return
+	}
+	cards, err := h.reviewService.NoteCards(user.UserID, noteID)
+	if err != nil {
+		h.noteError(ctx, err, "Failed to load cards")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"cards": cards})
+}
+
+// GenerateNoteCards replaces the note's cards, for notes written before
+// cards existed or when the generated set was poor.
+func (h *ReviewHandler) GenerateNoteCards(ctx *gin.Context) {
+	user, noteID, ok := h.noteRequest(ctx)
+	if !ok {
+		return
+	}
+	cards, err := h.reviewService.GenerateForNote(ctx.Request.Context(), user.UserID, noteID)
+	if err != nil {
+		h.noteError(ctx, err, "Failed to generate cards")
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"cards": cards})
+}
+
+func (h *ReviewHandler) noteRequest(ctx *gin.Context) (token.Principal, uint, bool) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return user, 0, false
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("noteId"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return user, 0, false
+	}
+	return user, uint(noteID), true
+}
+
+func (h *ReviewHandler) noteError(ctx *gin.Context, err error, message string) {
+	if errors.Is(err, ErrNoteNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, llm.ErrQuotaExceeded) {
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, llm.ErrBlocked) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": llm.ErrBlocked.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": message + ": " + err.Error()})
+}
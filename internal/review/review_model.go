@@ -0,0 +1,26 @@
+package review
+
+import "time"
+
+// Card is a flashcard generated from a note. The scheduling fields follow
+// SM-2: EaseFactor scales the interval after each successful review and
+// DueAt is when the card should be shown next.
+type Card struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id"`
+	NoteID         uint       `json:"note_id"`
+	Question       string     `json:"question"`
+	Answer         string     `json:"answer"`
+	EaseFactor     float64    `json:"ease_factor"`
+	IntervalDays   int        `json:"interval_days"`
+	Repetitions    int        `json:"repetitions"`
+	Lapses         int        `json:"lapses"`
+	DueAt          time.Time  `json:"due_at"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (Card) TableName() string {
+	return "review_cards"
+}
@@ -0,0 +1,63 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"notemind/internal/llm"
+)
+
+const (
+	maxCardsPerNote = 5
+	// maxSourceChars keeps very long notes from blowing up the prompt.
+	maxSourceChars = 6000
+)
+
+type generatedCard struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+func buildPrompt(note *SourceNote) string {
+	content := strings.TrimSpace(note.Content)
+	if utf8.RuneCountInString(content) > maxSourceChars {
+		content = string([]rune(content)[:maxSourceChars])
+	}
+	return fmt.Sprintf(`Write flashcards that help the author of the note below remember what they wrote.
+Write between 1 and %d cards depending on how much there is to remember. Each question must be answerable from the note alone, and each answer must be one or two sentences.
+Skip trivia such as dates the note was written. Write in the language of the note.
+
+Answer with JSON only, no markdown fences, as an array:
+[{"question": "...", "answer": "..."}]
+
+%s
+
+%s`, maxCardsPerNote, llm.UntrustedNotice, llm.Untrusted("note", "Title: "+note.Title+"\n\n"+content))
+}
+
+func parseCards(answer string) ([]generatedCard, error) {
+	answer = strings.TrimSpace(answer)
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, errors.New("LLM answer is not a JSON array")
+	}
+	var cards []generatedCard
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &cards); err != nil {
+		return nil, fmt.Errorf("LLM answer is not valid JSON: %w", err)
+	}
+
+	valid := cards[:0]
+	for _, c := range cards {
+		c.Question, c.Answer = strings.TrimSpace(c.Question), strings.TrimSpace(c.Answer)
+		if c.Question != "" && c.Answer != "" {
+			valid = append(valid, c)
+		}
+	}
+	if len(valid) > maxCardsPerNote {
+		valid = valid[:maxCardsPerNote]
+	}
+	return valid, nil
+}
@@ -0,0 +1,144 @@
+package review
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReviewRepo interface {
+	// ReplaceNoteCards swaps the note's cards for a freshly generated set.
+	// Cards asking a question the note already had a card for keep that
+	// card and its schedule; cards is updated with what was stored.
+	ReplaceNoteCards(noteID uint, cards []Card) error
+	GetCard(userID, cardID uint) (*Card, error)
+	UpdateCard(card *Card) error
+	// ListDue returns up to limit cards due by before, most overdue first.
+	ListDue(userID uint, before time.Time, limit int) ([]DueCard, int64, error)
+	ListNoteCards(userID, noteID uint) ([]Card, error)
+	// GetNote returns the note if it belongs to the user.
+	GetNote(userID, noteID uint) (*SourceNote, error)
+}
+
+// SourceNote is the part of a note cards are generated from.
+type SourceNote struct {
+	ID      uint
+	UserID  uint
+	Title   string
+	Content string
+}
+
+type reviewRepo struct {
+	db *gorm.DB
+}
+
+func NewReviewRepo(db *gorm.DB) ReviewRepo {
+	return &reviewRepo{db: db}
+}
+
+func (r *reviewRepo) ReplaceNoteCards(noteID uint, cards []Card) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing []Card
+		if err := tx.Where("note_id = ?", noteID).Order("id").Find(&existing).Error; err != nil {
+			return err
+		}
+		byQuestion := make(map[string]*Card, len(existing))
+		for i := range existing {
+			key := questionKey(existing[i].Question)
+			if _, ok := byQuestion[key]; !ok {
+				byQuestion[key] = &existing[i]
+			}
+		}
+
+		kept := make(map[uint]bool, len(existing))
+		var created []*Card
+		for i := range cards {
+			old, ok := byQuestion[questionKey(cards[i].Question)]
+			if !ok || kept[old.ID] {
+				created = append(created, &cards[i])
+				continue
+			}
+			kept[old.ID] = true
+			old.Question = cards[i].Question
+			old.Answer = cards[i].Answer
+			old.UpdatedAt = cards[i].UpdatedAt
+			if err := tx.Save(old).Error; err != nil {
+				return err
+			}
+			cards[i] = *old
+		}
+
+		var stale []uint
+		for _, card := range existing {
+			if !kept[card.ID] {
+				stale = append(stale, card.ID)
+			}
+		}
+		if len(stale) > 0 {
+			if err := tx.Delete(&Card{}, stale).Error; err != nil {
+				return err
+			}
+		}
+		for _, card := range created {
+			if err := tx.Create(card).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// questionKey is what two questions must share to count as the same card.
+func questionKey(question string) string {
+	return strings.ToLower(strings.Join(strings.Fields(question), " "))
+}
+
+func (r *reviewRepo) GetCard(userID, cardID uint) (*Card, error) {
+	var card Card
+	if err := r.db.Where("id = ? AND user_id = ?", cardID, userID).First(&card).Error; err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+func (r *reviewRepo) UpdateCard(card *Card) error {
+	return r.db.Save(card).Error
+}
+
+func (r *reviewRepo) ListDue(userID uint, before time.Time, limit int) ([]DueCard, int64, error) {
+	query := r.db.Table("review_cards").
+		Joins("JOIN notes ON notes.id = review_cards.note_id").
+		Where("review_cards.user_id = ? AND review_cards.due_at <= ?", userID, before.UTC()).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var cards []DueCard
+	err := query.
+		Select("review_cards.id, review_cards.note_id, notes.title AS note_title, review_cards.question, review_cards.answer, review_cards.due_at").
+		Order("review_cards.due_at, review_cards.id").
+		Limit(limit).
+		Scan(&cards).Error
+	return cards, total, err
+}
+
+func (r *reviewRepo) ListNoteCards(userID, noteID uint) ([]Card, error) {
+	var cards []Card
+	err := r.db.Where("user_id = ? AND note_id = ?", userID, noteID).Order("id").Find(&cards).Error
+	return cards, err
+}
+
+func (r *reviewRepo) GetNote(userID, noteID uint) (*SourceNote, error) {
+	var note SourceNote
+	err := r.db.Table("notes").
+		Select("id, user_id, title, content_text AS content").
+		Where("id = ? AND user_id = ?", noteID, userID).
+		Take(&note).Error
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
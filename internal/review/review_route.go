@@ -0,0 +1,18 @@
+package review
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, reviewHandler *ReviewHandler, authMiddleware gin.HandlerFunc) {
+	review := router.Group("/api/v1/review")
+	review.Use(authMiddleware)
+	{
+		review.GET("/due", token.RequireScope(token.ScopeNotesRead), reviewHandler.ListDue)
+		review.POST("/:cardId", token.RequireScope(token.ScopeNotesWrite), reviewHandler.GradeCard)
+		review.GET("/notes/:noteId/cards", token.RequireScope(token.ScopeNotesRead), reviewHandler.ListNoteCards)
+		review.POST("/notes/:noteId/cards", token.RequireScope(token.ScopeNotesWrite), reviewHandler.GenerateNoteCards)
+	}
+}
@@ -0,0 +1,46 @@
+package review
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// Grades run from 0 (complete blackout) to 5 (perfect recall); anything
+	// below MinPassingGrade counts as forgotten.
+	MinGrade        = 0
+	MaxGrade        = 5
+	MinPassingGrade = 3
+
+	initialEase = 2.5
+	minEase     = 1.3
+)
+
+// schedule applies an SM-2 review with grade to card at now.
+func schedule(card *Card, grade int, now time.Time) {
+	if grade >= MinPassingGrade {
+		switch card.Repetitions {
+		case 0:
+			card.IntervalDays = 1
+		case 1:
+			card.IntervalDays = 6
+		default:
+			card.IntervalDays = int(math.Round(float64(card.IntervalDays) * card.EaseFactor))
+		}
+		card.Repetitions++
+	} else {
+		card.Repetitions = 0
+		card.IntervalDays = 1
+		card.Lapses++
+	}
+
+	q := float64(MaxGrade - grade)
+	card.EaseFactor += 0.1 - q*(0.08+q*0.02)
+	if card.EaseFactor < minEase {
+		card.EaseFactor = minEase
+	}
+
+	reviewed := now.UTC()
+	card.LastReviewedAt = &reviewed
+	card.DueAt = reviewed.AddDate(0, 0, card.IntervalDays)
+}
@@ -0,0 +1,62 @@
+package review
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestScheduleGrades(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	tests := []struct {
+		grade       int
+		interval    int
+		repetitions int
+		lapses      int
+		ease        float64
+	}{
+		{grade: 0, interval: 1, repetitions: 0, lapses: 1, ease: 1.7},
+		{grade: 1, interval: 1, repetitions: 0, lapses: 1, ease: 1.96},
+		{grade: 2, interval: 1, repetitions: 0, lapses: 1, ease: 2.18},
+		{grade: 3, interval: 15, repetitions: 3, lapses: 0, ease: 2.36},
+		{grade: 4, interval: 15, repetitions: 3, lapses: 0, ease: 2.5},
+		{grade: 5, interval: 15, repetitions: 3, lapses: 0, ease: 2.6},
+	}
+	for _, tt := range tests {
+		card := Card{EaseFactor: initialEase, IntervalDays: 6, Repetitions: 2}
+		schedule(&card, tt.grade, now)
+
+		if card.IntervalDays != tt.interval || card.Repetitions != tt.repetitions || card.Lapses != tt.lapses {
+			t.Errorf("grade %d: interval %d, repetitions %d, lapses %d, want %d, %d, %d",
+				tt.grade, card.IntervalDays, card.Repetitions, card.Lapses, tt.interval, tt.repetitions, tt.lapses)
+		}
+		if math.Abs(card.EaseFactor-tt.ease) > 1e-9 {
+			t.Errorf("grade %d: ease %v, want %v", tt.grade, card.EaseFactor, tt.ease)
+		}
+		if want := now.UTC().AddDate(0, 0, tt.interval); !card.DueAt.Equal(want) || card.DueAt.Location() != time.UTC {
+			t.Errorf("grade %d: due %v, want %v", tt.grade, card.DueAt, want)
+		}
+		if card.LastReviewedAt == nil || !card.LastReviewedAt.Equal(now) {
+			t.Errorf("grade %d: last reviewed %v, want %v", tt.grade, card.LastReviewedAt, now)
+		}
+	}
+}
+
+func TestScheduleFirstReviews(t *testing.T) {
+	card := Card{EaseFactor: initialEase}
+	now := time.Now()
+	for i, want := range []int{1, 6, 15, 38} {
+		schedule(&card, 4, now)
+		if card.IntervalDays != want {
+			t.Errorf("review %d: interval %d, want %d", i+1, card.IntervalDays, want)
+		}
+	}
+}
+
+func TestScheduleKeepsMinimumEase(t *testing.T) {
+	card := Card{EaseFactor: minEase + 0.1, IntervalDays: 10, Repetitions: 4}
+	schedule(&card, MinGrade, time.Now())
+	if card.EaseFactor != minEase {
+		t.Errorf("ease %v, want it floored at %v", card.EaseFactor, minEase)
+	}
+}
@@ -0,0 +1,141 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"notemind/internal/llm"
+	"notemind/internal/note"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// minNoteChars skips notes too short to make a useful card from.
+	minNoteChars    = 80
+	generateTimeout = time.Minute
+)
+
+var (
+	ErrCardNotFound = errors.New("card not found")
+	ErrNoteNotFound = errors.New("note not found")
+	ErrInvalidGrade = fmt.Errorf("grade must be between %d and %d", MinGrade, MaxGrade)
+)
+
+type ReviewService interface {
+	// Due returns up to limit cards due now and how many are due in total.
+	Due(userID uint, limit int) ([]DueCard, int64, error)
+	Grade(userID, cardID uint, grade int) (*Card, error)
+	NoteCards(userID, noteID uint) ([]Card, error)
+	// GenerateForNote replaces the note's cards with a new set from the LLM.
+	GenerateForNote(ctx context.Context, userID, noteID uint) ([]Card, error)
+	// NoteSaved regenerates cards in the background whenever a note changes.
+	NoteSaved(n *note.Note)
+}
+
+type reviewService struct {
+	repo ReviewRepo
+	llm  llm.Client
+	now  func() time.Time
+}
+
+func NewReviewService(repo ReviewRepo, llm llm.Client) ReviewService {
+	return &reviewService{repo: repo, llm: llm, now: time.Now}
+}
+
+func (s *reviewService) Due(userID uint, limit int) ([]DueCard, int64, error) {
+	return s.repo.ListDue(userID, s.now(), limit)
+}
+
+func (s *reviewService) Grade(userID, cardID uint, grade int) (*Card, error) {
+	if grade < MinGrade || grade > MaxGrade {
+		return nil, ErrInvalidGrade
+	}
+	card, err := s.repo.GetCard(userID, cardID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCardNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schedule(card, grade, s.now())
+	if err := s.repo.UpdateCard(card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *reviewService) NoteCards(userID, noteID uint) ([]Card, error) {
+	if _, err := s.getNote(userID, noteID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListNoteCards(userID, noteID)
+}
+
+func (s *reviewService) GenerateForNote(ctx context.Context, userID, noteID uint) ([]Card, error) {
+	source, err := s.getNote(userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	return s.generate(ctx, source)
+}
+
+func (s *reviewService) NoteSaved(n *note.Note) {
+	source := &SourceNote{ID: n.ID, UserID: n.UserID, Title: n.Title, Content: n.ContentText}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), generateTimeout)
+		defer cancel()
+		if _, err := s.generate(ctx, source); err != nil {
+			log.Printf("review: failed to generate cards for note %d: %v", source.ID, err)
+		}
+	}()
+}
+
+func (s *reviewService) generate(ctx context.Context, source *SourceNote) ([]Card, error) {
+	if len(strings.TrimSpace(source.Content)) < minNoteChars {
+		// nothing worth remembering, and stale cards would no longer match
+		return nil, s.repo.ReplaceNoteCards(source.ID, nil)
+	}
+
+	answer, err := s.llm.Generate(llm.ForUser(ctx, source.UserID, llm.FeatureReview), buildPrompt(source))
+	if err != nil {
+		return nil, err
+	}
+	generated, err := parseCards(answer)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	cards := make([]Card, 0, len(generated))
+	for _, g := range generated {
+		cards = append(cards, Card{
+			UserID:     source.UserID,
+			NoteID:     source.ID,
+			Question:   g.Question,
+			Answer:     g.Answer,
+			EaseFactor: initialEase,
+			// first review the day after the note was written
+			DueAt:     now.AddDate(0, 0, 1),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	if err := s.repo.ReplaceNoteCards(source.ID, cards); err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+func (s *reviewService) getNote(userID, noteID uint) (*SourceNote, error) {
+	source, err := s.repo.GetNote(userID, noteID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoteNotFound
+	}
+	return source, err
+}
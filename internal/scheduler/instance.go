@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// InstanceID identifies this replica to the background work it owns, so
+// that work a stopped replica left behind can be told apart from work a
+// live one is still doing. It is INSTANCE_ID or else the host name, both
+// of which stay the same when the replica restarts.
+func InstanceID() string {
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		return id
+	}
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		log.Printf("scheduler: no host name, set INSTANCE_ID to tell replicas apart: %v", err)
+		return "default"
+	}
+	return host
+}
+
+// Heartbeat calls beat every interval until the returned stop function is
+// called, so that others can see a long running job is still alive.
+func Heartbeat(name string, interval time.Duration, beat func(now time.Time) error) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := beat(time.Now().UTC()); err != nil {
+					log.Printf("%s: heartbeat failed: %v", name, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job is one unit of periodic work.
+type Job func(ctx context.Context) error
+
+// Scheduler runs a job periodically on a single replica. Replicas elect a
+// leader through a Postgres session advisory lock; the leader keeps the
+// connection holding the lock open, and another replica takes over as soon
+// as that connection goes away.
+type Scheduler struct {
+	db       *gorm.DB
+	name     string
+	lockKey  int64
+	interval time.Duration
+	job      Job
+
+	conn *sql.Conn
+}
+
+func New(db *gorm.DB, name string, interval time.Duration, job Job) *Scheduler {
+	h := fnv.New64a()
+	h.Write([]byte("notemind:scheduler:" + name))
+	return &Scheduler{
+		db:       db,
+		name:     name,
+		lockKey:  int64(h.Sum64()),
+		interval: interval,
+		job:      job,
+	}
+}
+
+// Run blocks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	defer s.resign()
+
+	for {
+		if s.lead(ctx) {
+			if err := s.job(ctx); err != nil {
+				log.Printf("scheduler %s: job failed: %v", s.name, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead reports whether this replica is the leader, trying to become one if not.
+func (s *Scheduler) lead(ctx context.Context) bool {
+	if s.conn != nil {
+		if err := s.conn.PingContext(ctx); err == nil {
+			return true
+		}
+		log.Printf("scheduler %s: lost leader connection", s.name)
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		log.Printf("scheduler %s: %v", s.name, err)
+		return false
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Printf("scheduler %s: failed to get connection: %v", s.name, err)
+		return false
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", s.lockKey).Scan(&acquired); err != nil {
+		log.Printf("scheduler %s: failed to try advisory lock: %v", s.name, err)
+		conn.Close()
+		return false
+	}
+	if !acquired {
+		conn.Close()
+		return false
+	}
+
+	log.Printf("scheduler %s: this replica is now the leader", s.name)
+	s.conn = conn
+	return true
+}
+
+func (s *Scheduler) resign() {
+	if s.conn == nil {
+		return
+	}
+	// the context passed to Run is already done at this point
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", s.lockKey); err != nil {
+		log.Printf("scheduler %s: failed to release advisory lock: %v", s.name, err)
+	}
+	s.conn.Close()
+	s.conn = nil
+}
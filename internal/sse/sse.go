@@ -0,0 +1,27 @@
+package sse
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Begin switches the response to a Server-Sent Events stream.
+func Begin(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// keeps nginx style proxies from buffering the whole stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+	c.Writer.Flush()
+}
+
+// Send writes one event and flushes it to the client right away. It fails
+// once the client has gone away.
+func Send(c *gin.Context, event string, data interface{}) error {
+	if err := c.Request.Context().Err(); err != nil {
+		return err
+	}
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+	return nil
+}
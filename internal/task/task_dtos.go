@@ -0,0 +1,34 @@
+package task
+
+import "time"
+
+// Filters of the task list.
+const (
+	StatusOpen = "open"
+	StatusDone = "done"
+	StatusAll  = "all"
+
+	DueOverdue = "overdue"
+	DueToday   = "today"
+	DueWeek    = "week"
+)
+
+type ListQuery struct {
+	Status string `form:"status"`
+	// Due narrows the list to overdue tasks, tasks due by the end of today
+	// or by the end of the next seven days.
+	Due    string `form:"due"`
+	NoteID uint   `form:"note_id"`
+}
+
+type UpdateTaskRequest struct {
+	Completed *bool `json:"completed" binding:"required"`
+}
+
+// ListFilter is ListQuery resolved against the current date.
+type ListFilter struct {
+	Completed *bool
+	// DueBefore keeps tasks due strictly before this date.
+	DueBefore *time.Time
+	NoteID    uint
+}
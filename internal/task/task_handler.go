@@ -0,0 +1,108 @@
+package task
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"notemind/internal/llm"
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaskHandler struct {
+	taskService TaskService
+}
+
+func NewTaskHandler(taskService TaskService) *TaskHandler {
+	return &TaskHandler{taskService: taskService}
+}
+
+func (h *TaskHandler) ListTasks(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	var query ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tasks, err := h.taskService.List(user.UserID, query)
+	if err != nil {
+		if errors.Is(err, ErrInvalidFilter) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// UpdateTask marks a task done or reopens it.
+func (h *TaskHandler) UpdateTask(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	taskID, err := strconv.ParseUint(ctx.Param("taskId"), 10, 32)
+	if err != nil || taskID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	var req UpdateTaskRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.taskService.SetCompleted(user.UserID, uint(taskID), *req.Completed)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// ExtractNoteTasks re-extracts the note's open tasks, for notes written
+// before tasks existed or when the extraction missed something.
+func (h *TaskHandler) ExtractNoteTasks(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	noteID, err := strconv.ParseUint(ctx.Param("noteId"), 10, 32)
+	if err != nil || noteID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid note ID"})
+		return
+	}
+
+	tasks, err := h.taskService.ExtractForNote(ctx.Request.Context(), user.UserID, uint(noteID))
+	if err != nil {
+		if errors.Is(err, ErrNoteNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, llm.ErrQuotaExceeded) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, llm.ErrBlocked) {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": llm.ErrBlocked.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to extract tasks: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"tasks": tasks})
+}
@@ -0,0 +1,18 @@
+package task
+
+import "time"
+
+// Task is an action item extracted from a note. DueDate is a calendar date
+// stored as UTC midnight.
+type Task struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id"`
+	NoteID      uint       `json:"note_id"`
+	Text        string     `json:"text"`
+	Assignee    string     `json:"assignee"`
+	DueDate     *time.Time `json:"due_date" gorm:"type:date"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,79 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"notemind/internal/llm"
+)
+
+const (
+	maxTasksPerNote = 20
+	// maxSourceChars keeps very long notes from blowing up the prompt.
+	maxSourceChars = 8000
+	dateLayout     = "2006-01-02"
+)
+
+type extractedTask struct {
+	Text     string `json:"text"`
+	Assignee string `json:"assignee"`
+	// DueDate is YYYY-MM-DD, or empty when the note names no date.
+	DueDate string `json:"due_date"`
+}
+
+func buildPrompt(note *SourceNote) string {
+	content := strings.TrimSpace(note.Content)
+	if utf8.RuneCountInString(content) > maxSourceChars {
+		content = string([]rune(content)[:maxSourceChars])
+	}
+	return fmt.Sprintf(`Extract the action items from the note below: todos, follow-ups and commitments someone has to act on.
+Ignore things that already happened, ideas without a commitment, and general information. Write each action item as a short imperative sentence in the language of the note.
+Set "assignee" to the person responsible when the note names one, otherwise leave it empty.
+Set "due_date" to the due date as YYYY-MM-DD when the note gives one, otherwise leave it empty. The note was written on %s (%s); resolve relative dates such as "next Friday" from that day.
+Return at most %d action items, and an empty array when there are none.
+
+Answer with JSON only, no markdown fences, as an array:
+[{"text": "...", "assignee": "...", "due_date": "..."}]
+
+%s
+
+%s`, note.CreatedAt.Format(dateLayout), note.CreatedAt.Weekday(), maxTasksPerNote,
+		llm.UntrustedNotice, llm.Untrusted("note", "Title: "+note.Title+"\n\n"+content))
+}
+
+func parseTasks(answer string) ([]extractedTask, error) {
+	answer = strings.TrimSpace(answer)
+	start, end := strings.Index(answer, "["), strings.LastIndex(answer, "]")
+	if start < 0 || end < start {
+		return nil, errors.New("LLM answer is not a JSON array")
+	}
+	var tasks []extractedTask
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &tasks); err != nil {
+		return nil, fmt.Errorf("LLM answer is not valid JSON: %w", err)
+	}
+
+	valid := tasks[:0]
+	for _, t := range tasks {
+		t.Text, t.Assignee = strings.TrimSpace(t.Text), strings.TrimSpace(t.Assignee)
+		if t.Text != "" {
+			valid = append(valid, t)
+		}
+	}
+	if len(valid) > maxTasksPerNote {
+		valid = valid[:maxTasksPerNote]
+	}
+	return valid, nil
+}
+
+// dueDate parses the extracted due date, dropping anything that is not a date.
+func (t extractedTask) dueDate() *time.Time {
+	d, err := time.Parse(dateLayout, strings.TrimSpace(t.DueDate))
+	if err != nil {
+		return nil
+	}
+	return &d
+}
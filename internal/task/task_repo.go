@@ -0,0 +1,95 @@
+package task
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TaskRepo interface {
+	// ReplaceOpenNoteTasks swaps the note's open tasks for a freshly
+	// extracted set. Completed tasks are kept.
+	ReplaceOpenNoteTasks(noteID uint, tasks []Task) error
+	ListNoteTasks(userID, noteID uint) ([]Task, error)
+	List(userID uint, filter ListFilter) ([]Task, error)
+	GetTask(userID, taskID uint) (*Task, error)
+	UpdateTask(task *Task) error
+	// GetNote returns the note if it belongs to the user.
+	GetNote(userID, noteID uint) (*SourceNote, error)
+}
+
+// SourceNote is the part of a note tasks are extracted from.
+type SourceNote struct {
+	ID        uint
+	UserID    uint
+	Title     string
+	Content   string
+	CreatedAt time.Time
+}
+
+type taskRepo struct {
+	db *gorm.DB
+}
+
+func NewTaskRepo(db *gorm.DB) TaskRepo {
+	return &taskRepo{db: db}
+}
+
+func (r *taskRepo) ReplaceOpenNoteTasks(noteID uint, tasks []Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("note_id = ? AND completed = false", noteID).Delete(&Task{}).Error; err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+		return tx.Create(&tasks).Error
+	})
+}
+
+func (r *taskRepo) ListNoteTasks(userID, noteID uint) ([]Task, error) {
+	var tasks []Task
+	err := r.db.Where("user_id = ? AND note_id = ?", userID, noteID).Order("id").Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *taskRepo) List(userID uint, filter ListFilter) ([]Task, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if filter.Completed != nil {
+		query = query.Where("completed = ?", *filter.Completed)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("due_date < ?", filter.DueBefore.Format(dateLayout))
+	}
+	if filter.NoteID != 0 {
+		query = query.Where("note_id = ?", filter.NoteID)
+	}
+
+	var tasks []Task
+	err := query.Order("due_date NULLS LAST, id").Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *taskRepo) GetTask(userID, taskID uint) (*Task, error) {
+	var task Task
+	if err := r.db.Where("id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *taskRepo) UpdateTask(task *Task) error {
+	return r.db.Save(task).Error
+}
+
+func (r *taskRepo) GetNote(userID, noteID uint) (*SourceNote, error) {
+	var note SourceNote
+	err := r.db.Table("notes").
+		Select("id, user_id, title, content_text AS content, created_at").
+		Where("id = ? AND user_id = ?", noteID, userID).
+		Take(&note).Error
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
@@ -0,0 +1,17 @@
+package task
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, taskHandler *TaskHandler, authMiddleware gin.HandlerFunc) {
+	tasks := router.Group("/api/v1/tasks")
+	tasks.Use(authMiddleware)
+	{
+		tasks.GET("", token.RequireScope(token.ScopeNotesRead), taskHandler.ListTasks)
+		tasks.PATCH("/:taskId", token.RequireScope(token.ScopeNotesWrite), taskHandler.UpdateTask)
+		tasks.POST("/notes/:noteId", token.RequireScope(token.ScopeNotesWrite), taskHandler.ExtractNoteTasks)
+	}
+}
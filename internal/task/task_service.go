@@ -0,0 +1,184 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"notemind/internal/llm"
+	"notemind/internal/note"
+
+	"gorm.io/gorm"
+)
+
+const extractTimeout = time.Minute
+
+var (
+	ErrTaskNotFound  = errors.New("task not found")
+	ErrNoteNotFound  = errors.New("note not found")
+	ErrInvalidFilter = errors.New("status must be open, done or all and due must be overdue, today or week")
+)
+
+type TaskService interface {
+	// List returns the user's tasks, soonest due first. Due filters use the
+	// UTC calendar day.
+	List(userID uint, query ListQuery) ([]Task, error)
+	SetCompleted(userID, taskID uint, completed bool) (*Task, error)
+	// ExtractForNote replaces the note's open tasks with a new set from the LLM.
+	ExtractForNote(ctx context.Context, userID, noteID uint) ([]Task, error)
+	// NoteSaved extracts tasks in the background whenever a note changes.
+	NoteSaved(n *note.Note)
+}
+
+type taskService struct {
+	repo TaskRepo
+	llm  llm.Client
+	now  func() time.Time
+}
+
+func NewTaskService(repo TaskRepo, llm llm.Client) TaskService {
+	return &taskService{repo: repo, llm: llm, now: time.Now}
+}
+
+func (s *taskService) List(userID uint, query ListQuery) ([]Task, error) {
+	filter := ListFilter{NoteID: query.NoteID}
+
+	switch query.Status {
+	case "", StatusOpen:
+		open := false
+		filter.Completed = &open
+	case StatusDone:
+		done := true
+		filter.Completed = &done
+	case StatusAll:
+	default:
+		return nil, ErrInvalidFilter
+	}
+
+	today := dateOnly(s.now())
+	var before time.Time
+	switch query.Due {
+	case "":
+	case DueOverdue:
+		before = today
+	case DueToday:
+		before = today.AddDate(0, 0, 1)
+	case DueWeek:
+		before = today.AddDate(0, 0, 7)
+	default:
+		return nil, ErrInvalidFilter
+	}
+	if !before.IsZero() {
+		filter.DueBefore = &before
+	}
+
+	return s.repo.List(userID, filter)
+}
+
+func (s *taskService) SetCompleted(userID, taskID uint, completed bool) (*Task, error) {
+	task, err := s.repo.GetTask(userID, taskID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if task.Completed == completed {
+		return task, nil
+	}
+
+	now := s.now().UTC()
+	task.Completed = completed
+	task.CompletedAt = nil
+	if completed {
+		task.CompletedAt = &now
+	}
+	task.UpdatedAt = now
+	if err := s.repo.UpdateTask(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (s *taskService) ExtractForNote(ctx context.Context, userID, noteID uint) ([]Task, error) {
+	source, err := s.repo.GetNote(userID, noteID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.extract(ctx, source)
+}
+
+func (s *taskService) NoteSaved(n *note.Note) {
+	source := &SourceNote{ID: n.ID, UserID: n.UserID, Title: n.Title, Content: n.ContentText, CreatedAt: n.CreatedAt}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), extractTimeout)
+		defer cancel()
+		if _, err := s.extract(ctx, source); err != nil {
+			log.Printf("task: failed to extract tasks for note %d: %v", source.ID, err)
+		}
+	}()
+}
+
+func (s *taskService) extract(ctx context.Context, source *SourceNote) ([]Task, error) {
+	if strings.TrimSpace(source.Content) == "" {
+		return nil, s.repo.ReplaceOpenNoteTasks(source.ID, nil)
+	}
+
+	answer, err := s.llm.Generate(llm.ForUser(ctx, source.UserID, llm.FeatureTasks), buildPrompt(source))
+	if err != nil {
+		return nil, err
+	}
+	extracted, err := parseTasks(answer)
+	if err != nil {
+		return nil, err
+	}
+
+	// an edit re-extracts everything, tasks the user already ticked off
+	// must not come back as open ones
+	existing, err := s.repo.ListNoteTasks(source.UserID, source.ID)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool)
+	for _, t := range existing {
+		if t.Completed {
+			done[normalize(t.Text)] = true
+		}
+	}
+
+	now := s.now().UTC()
+	tasks := make([]Task, 0, len(extracted))
+	for _, e := range extracted {
+		if done[normalize(e.Text)] {
+			continue
+		}
+		tasks = append(tasks, Task{
+			UserID:    source.UserID,
+			NoteID:    source.ID,
+			Text:      e.Text,
+			Assignee:  e.Assignee,
+			DueDate:   e.dueDate(),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	if err := s.repo.ReplaceOpenNoteTasks(source.ID, tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func normalize(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// dateOnly is the UTC calendar day of t, the way date columns are stored.
+func dateOnly(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
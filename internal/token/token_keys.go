@@ -0,0 +1,87 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is one entry of the key set. Keys loaded from a public PEM can
+// only verify tokens, which is how retired keys are kept around during rotation.
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+func (k *signingKey) canSign() bool {
+	return k.sign != nil
+}
+
+// parseKeySpec parses JWT_KEYS, a comma separated list of kid:ALG:value.
+// For HS256 the value is the shared secret, for RS256 and EdDSA it is the
+// path of a PEM file holding either a private key or a public key.
+func parseKeySpec(spec string) ([]*signingKey, error) {
+	var keys []*signingKey
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid key entry %q, expected kid:ALG:value", entry)
+		}
+		key, err := loadKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", parts[0], err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func loadKey(id, alg, value string) (*signingKey, error) {
+	switch alg {
+	case "HS256":
+		secret := []byte(value)
+		return &signingKey{id: id, method: jwt.SigningMethodHS256, sign: secret, verify: secret}, nil
+	case "RS256":
+		pem, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		if priv, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+			return &signingKey{id: id, method: jwt.SigningMethodRS256, sign: priv, verify: &priv.PublicKey}, nil
+		}
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, errors.New("file is neither an RSA private nor public key")
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, verify: pub}, nil
+	case "EdDSA":
+		pem, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		if priv, err := jwt.ParseEdPrivateKeyFromPEM(pem); err == nil {
+			signer, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, errors.New("unsupported EdDSA private key")
+			}
+			return &signingKey{id: id, method: jwt.SigningMethodEdDSA, sign: signer, verify: signer.Public()}, nil
+		}
+		pub, err := jwt.ParseEdPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, errors.New("file is neither an Ed25519 private nor public key")
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodEdDSA, verify: pub}, nil
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
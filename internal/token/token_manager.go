@@ -0,0 +1,186 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultIssuer   = "notemind"
+	defaultAudience = "notemind-api"
+	defaultTTL      = 24 * time.Hour
+	legacyKeyID     = "default"
+)
+
+// Claims is the payload of every access token issued by the API.
+type Claims struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Config describes the key set and the claims the manager enforces.
+type Config struct {
+	Issuer      string
+	Audience    string
+	TTL         time.Duration
+	ActiveKeyID string
+	// Keys uses the JWT_KEYS format, see parseKeySpec.
+	Keys string
+}
+
+// Manager signs and validates access tokens. It keeps several keys active at
+// once, selected by the kid header, so keys can be rotated without logging
+// everybody out.
+type Manager struct {
+	issuer   string
+	audience string
+	ttl      time.Duration
+	keys     map[string]*signingKey
+	active   *signingKey
+	methods  []string
+	apiKeys  KeyAuthenticator
+	accounts AccountChecker
+}
+
+// NewManagerFromEnv builds a Manager from JWT_* variables, falling back to
+// SECRET_KEY as a single HS256 key when JWT_KEYS is not set.
+func NewManagerFromEnv() (*Manager, error) {
+	cfg := Config{
+		Issuer:      os.Getenv("JWT_ISSUER"),
+		Audience:    os.Getenv("JWT_AUDIENCE"),
+		ActiveKeyID: os.Getenv("JWT_ACTIVE_KID"),
+		Keys:        os.Getenv("JWT_KEYS"),
+	}
+	if ttl := os.Getenv("JWT_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_TTL: %w", err)
+		}
+		cfg.TTL = d
+	}
+	if cfg.Keys == "" {
+		secretKey := os.Getenv("SECRET_KEY")
+		if secretKey == "" {
+			return nil, errors.New("JWT_KEYS or SECRET_KEY must be set")
+		}
+		cfg.Keys = legacyKeyID + ":HS256:" + secretKey
+	}
+	return NewManager(cfg)
+}
+
+func NewManager(cfg Config) (*Manager, error) {
+	keys, err := parseKeySpec(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys configured")
+	}
+
+	m := &Manager{
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.TTL,
+		keys:     make(map[string]*signingKey, len(keys)),
+	}
+	if m.issuer == "" {
+		m.issuer = defaultIssuer
+	}
+	if m.audience == "" {
+		m.audience = defaultAudience
+	}
+	if m.ttl <= 0 {
+		m.ttl = defaultTTL
+	}
+
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if _, dup := m.keys[k.id]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", k.id)
+		}
+		m.keys[k.id] = k
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			m.methods = append(m.methods, alg)
+		}
+	}
+
+	activeID := cfg.ActiveKeyID
+	if activeID == "" {
+		activeID = keys[0].id
+	}
+	active, ok := m.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q is not configured", activeID)
+	}
+	if !active.canSign() {
+		return nil, fmt.Errorf("active key %q has no private key", activeID)
+	}
+	m.active = active
+
+	return m, nil
+}
+
+// Sign issues an access token for the user with the active key.
+func (m *Manager) Sign(userID uint, email, role string) (string, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID: userID,
+		Email:  email,
+		Role:   role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Audience:  jwt.ClaimStrings{m.audience},
+			Subject:   fmt.Sprint(userID),
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token := jwt.NewWithClaims(m.active.method, claims)
+	token.Header["kid"] = m.active.id
+	return token.SignedString(m.active.sign)
+}
+
+// Validate checks the signature, issuer, audience and expiry of a token.
+func (m *Manager) Validate(tokenString string) (*Claims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(m.methods),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(m.audience),
+		jwt.WithExpirationRequired(),
+	)
+	parsedToken, err := parser.ParseWithClaims(tokenString, &Claims{}, m.keyFor)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := parsedToken.Claims.(*Claims)
+	if !ok || !parsedToken.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if claims.UserID == 0 {
+		return nil, errors.New("token has no user")
+	}
+	return claims, nil
+}
+
+func (m *Manager) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no key id")
+	}
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("invalid signing method")
+	}
+	return key.verify, nil
+}
@@ -0,0 +1,211 @@
+package token
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const currentUserKey = "current_user"
+
+// Scopes that can be granted to API keys. Access tokens carry all of them.
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+)
+
+// RoleAdmin marks operators allowed to run maintenance endpoints.
+const RoleAdmin = "admin"
+
+// Scopes lists every scope an API key may be granted.
+var Scopes = []string{ScopeNotesRead, ScopeNotesWrite}
+
+// APIKeyPrefix starts every personal API key, which lets the middleware tell
+// them apart from access tokens in the Authorization header.
+const APIKeyPrefix = "nmk_"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID uint
+	Email  string
+	// Role is only carried by access tokens, API keys never act as admins.
+	Role string
+	// APIKeyID is set when the caller used an API key instead of an access token.
+	APIKeyID uint
+	Scopes   []string
+}
+
+// HasScope reports whether the caller may act within scope.
+func (p Principal) HasScope(scope string) bool {
+	if p.APIKeyID == 0 {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyAuthenticator resolves a raw API key to its owner.
+type KeyAuthenticator interface {
+	Authenticate(rawKey string) (*Principal, error)
+}
+
+// UseAPIKeys makes the middleware accept API keys, either as a bearer
+// credential or in the X-API-Key header.
+func (m *Manager) UseAPIKeys(keys KeyAuthenticator) {
+	m.apiKeys = keys
+}
+
+// AccountChecker tells whether a user may still use their account.
+type AccountChecker interface {
+	AccountActive(userID uint) (bool, error)
+}
+
+// UseAccounts makes the middleware reject access tokens of users who were
+// deleted or asked to be since the token was issued. API keys check this
+// on their own.
+func (m *Manager) UseAccounts(accounts AccountChecker) {
+	m.accounts = accounts
+}
+
+// Middleware authenticates requests carrying a bearer access token. Besides
+// the typed value read by CurrentUser it keeps setting user_id and user_email
+// for handlers that read them directly.
+func (m *Manager) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, failure := m.authenticate(c)
+		if failure != nil {
+			c.JSON(401, failure)
+			c.Abort()
+			return
+		}
+		setCurrentUser(c, *principal)
+		c.Next()
+	}
+}
+
+// authenticate resolves the caller from the request headers. On failure it
+// returns the body of the 401 response instead.
+func (m *Manager) authenticate(c *gin.Context) (*Principal, gin.H) {
+	if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+		return m.authenticateAPIKey(rawKey)
+	}
+
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		log.Println("token is missing")
+		return nil, gin.H{"error": "Authorization Header Required"}
+	}
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, gin.H{"error": "Authorization header must start with Bearer"}
+	}
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if strings.HasPrefix(tokenString, APIKeyPrefix) {
+		return m.authenticateAPIKey(tokenString)
+	}
+
+	claims, err := m.Validate(tokenString)
+	if err != nil {
+		return nil, gin.H{"error": "Invalid token", "details": err.Error()}
+	}
+	if m.accounts != nil {
+		active, err := m.accounts.AccountActive(claims.UserID)
+		if err != nil {
+			log.Printf("failed to check account %d: %v", claims.UserID, err)
+			return nil, gin.H{"error": "Invalid token"}
+		}
+		if !active {
+			return nil, gin.H{"error": "Account is scheduled for deletion"}
+		}
+	}
+	return &Principal{UserID: claims.UserID, Email: claims.Email, Role: claims.Role}, nil
+}
+
+func (m *Manager) authenticateAPIKey(rawKey string) (*Principal, gin.H) {
+	if m.apiKeys == nil {
+		return nil, gin.H{"error": "API keys are not accepted"}
+	}
+	principal, err := m.apiKeys.Authenticate(rawKey)
+	if err != nil {
+		return nil, gin.H{"error": "Invalid API key", "details": err.Error()}
+	}
+	return principal, nil
+}
+
+// RequireScope rejects API key callers that were not granted scope.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.JSON(401, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+		if !user.HasScope(scope) {
+			c.JSON(403, gin.H{"error": "API key is missing scope " + scope})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession only lets through callers holding an access token, for
+// endpoints that API keys must never reach such as key management itself.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.JSON(401, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+		if user.APIKeyID != 0 {
+			c.JSON(403, gin.H{"error": "This endpoint cannot be used with an API key"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole only lets through callers whose access token carries role.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.JSON(401, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+		if user.APIKeyID != 0 || user.Role != role {
+			c.JSON(403, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func setCurrentUser(c *gin.Context, user Principal) {
+	c.Set(currentUserKey, user)
+	c.Set("user_id", user.UserID)
+	c.Set("user_email", user.Email)
+}
+
+// CurrentUser returns the authenticated caller set by the middleware.
+func CurrentUser(c *gin.Context) (Principal, bool) {
+	value, exists := c.Get(currentUserKey)
+	if !exists {
+		return Principal{}, false
+	}
+	user, ok := value.(Principal)
+	if !ok || user.UserID == 0 {
+		return Principal{}, false
+	}
+	return user, true
+}
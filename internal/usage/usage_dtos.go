@@ -0,0 +1,37 @@
+package usage
+
+import "time"
+
+// Totals sums up a set of calls.
+type Totals struct {
+	Calls  int64 `json:"calls"`
+	Tokens int64 `json:"tokens"`
+}
+
+// DailyUsage is a user's usage of one feature on one UTC day.
+type DailyUsage struct {
+	Date    string `json:"date"`
+	Feature string `json:"feature"`
+	Calls   int64  `json:"calls"`
+	Tokens  int64  `json:"tokens"`
+}
+
+// Quota is a user's effective daily limits, 0 meaning unlimited.
+type Quota struct {
+	DailyTokens int `json:"daily_tokens"`
+	DailyCalls  int `json:"daily_calls"`
+}
+
+// Report is what /me/usage returns.
+type Report struct {
+	Today Totals `json:"today"`
+	Quota Quota  `json:"quota"`
+	// ResetsAt is when today's usage stops counting against the quota.
+	ResetsAt time.Time    `json:"resets_at"`
+	Days     []DailyUsage `json:"days"`
+}
+
+type BudgetRequest struct {
+	DailyTokens *int `json:"daily_tokens" binding:"omitempty,min=0"`
+	DailyCalls  *int `json:"daily_calls" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,83 @@
+package usage
+
+import (
+	"net/http"
+	"strconv"
+
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultReportDays = 30
+
+type UsageHandler struct {
+	usageService UsageService
+}
+
+func NewUsageHandler(usageService UsageService) *UsageHandler {
+	return &UsageHandler{usageService: usageService}
+}
+
+// GetMyUsage shows users how much of today's AI quota they used, and their
+// usage over the last ?days= days.
+func (h *UsageHandler) GetMyUsage(ctx *gin.Context) {
+	user, ok := token.CurrentUser(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	h.report(ctx, user.UserID)
+}
+
+func (h *UsageHandler) GetUserUsage(ctx *gin.Context) {
+	userID, ok := userParam(ctx)
+	if !ok {
+		return
+	}
+	h.report(ctx, userID)
+}
+
+// SetUserBudget lets an admin raise or lower a user's daily quotas.
+func (h *UsageHandler) SetUserBudget(ctx *gin.Context) {
+	userID, ok := userParam(ctx)
+	if !ok {
+		return
+	}
+	var req BudgetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := h.usageService.SetBudget(userID, req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save budget"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"budget": budget})
+}
+
+func (h *UsageHandler) report(ctx *gin.Context, userID uint) {
+	days, err := strconv.Atoi(ctx.DefaultQuery("days", strconv.Itoa(defaultReportDays)))
+	if err != nil || days < 1 || days > maxReportDays {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "days must be between 1 and 90"})
+		return
+	}
+
+	report, err := h.usageService.Report(userID, days)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server error"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"usage": report})
+}
+
+func userParam(ctx *gin.Context) (uint, bool) {
+	userID, err := strconv.ParseUint(ctx.Param("userId"), 10, 32)
+	if err != nil || userID == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+	return uint(userID), true
+}
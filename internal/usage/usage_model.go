@@ -0,0 +1,35 @@
+package usage
+
+import "time"
+
+// Usage is one call to the LLM. UserID is nil for calls not made on behalf
+// of a user.
+type Usage struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       *uint     `json:"user_id"`
+	Feature      string    `json:"feature"`
+	Model        string    `json:"model"`
+	PromptTokens int       `json:"prompt_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	TotalTokens  int       `json:"total_tokens"`
+	LatencyMs    int64     `json:"latency_ms"`
+	Failed       bool      `json:"failed"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (Usage) TableName() string {
+	return "llm_usage"
+}
+
+// Budget overrides the default daily quotas for one user. A nil limit falls
+// back to the default, 0 means unlimited.
+type Budget struct {
+	UserID      uint      `json:"user_id" gorm:"primaryKey"`
+	DailyTokens *int      `json:"daily_tokens"`
+	DailyCalls  *int      `json:"daily_calls"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Budget) TableName() string {
+	return "llm_budgets"
+}
@@ -0,0 +1,69 @@
+package usage
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UsageRepo interface {
+	Create(usage *Usage) error
+	// TotalsSince sums the user's calls made at or after since.
+	TotalsSince(userID uint, since time.Time) (Totals, error)
+	// Daily breaks the user's calls made at or after since down by UTC day
+	// and feature, newest day first.
+	Daily(userID uint, since time.Time) ([]DailyUsage, error)
+	// GetBudget returns nil if the user has no budget of their own.
+	GetBudget(userID uint) (*Budget, error)
+	SaveBudget(budget *Budget) error
+}
+
+type usageRepo struct {
+	db *gorm.DB
+}
+
+func NewUsageRepo(db *gorm.DB) UsageRepo {
+	return &usageRepo{db: db}
+}
+
+func (r *usageRepo) Create(usage *Usage) error {
+	return r.db.Create(usage).Error
+}
+
+func (r *usageRepo) TotalsSince(userID uint, since time.Time) (Totals, error) {
+	var totals Totals
+	err := r.db.Model(&Usage{}).
+		Select("COUNT(*) AS calls, COALESCE(SUM(total_tokens), 0) AS tokens").
+		Where("user_id = ? AND created_at >= ?", userID, since.UTC()).
+		Scan(&totals).Error
+	return totals, err
+}
+
+func (r *usageRepo) Daily(userID uint, since time.Time) ([]DailyUsage, error) {
+	var days []DailyUsage
+	err := r.db.Model(&Usage{}).
+		Select(`TO_CHAR(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date, feature,
+			COUNT(*) AS calls, COALESCE(SUM(total_tokens), 0) AS tokens`).
+		Where("user_id = ? AND created_at >= ?", userID, since.UTC()).
+		Group("date, feature").
+		Order("date DESC, feature").
+		Scan(&days).Error
+	return days, err
+}
+
+func (r *usageRepo) GetBudget(userID uint) (*Budget, error) {
+	var budget Budget
+	err := r.db.Where("user_id = ?", userID).First(&budget).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *usageRepo) SaveBudget(budget *Budget) error {
+	return r.db.Save(budget).Error
+}
@@ -0,0 +1,22 @@
+package usage
+
+import (
+	"notemind/internal/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetUpRoutes(router *gin.Engine, usageHandler *UsageHandler, authMiddleware gin.HandlerFunc) {
+	me := router.Group("/api/v1/me")
+	me.Use(authMiddleware)
+	{
+		me.GET("/usage", usageHandler.GetMyUsage)
+	}
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(authMiddleware, token.RequireRole(token.RoleAdmin))
+	{
+		admin.GET("/users/:userId/usage", usageHandler.GetUserUsage)
+		admin.PUT("/users/:userId/llm-budget", usageHandler.SetUserBudget)
+	}
+}
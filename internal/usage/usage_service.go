@@ -0,0 +1,160 @@
+package usage
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"notemind/internal/llm"
+)
+
+const (
+	defaultDailyTokens = 200000
+	defaultDailyCalls  = 500
+	maxReportDays      = 90
+)
+
+type UsageService interface {
+	llm.Meter
+	// Report returns the user's usage today against their quota and the
+	// breakdown of the last days.
+	Report(userID uint, days int) (*Report, error)
+	// SetBudget overrides the default quotas for the user; nil limits go
+	// back to the default.
+	SetBudget(userID uint, req BudgetRequest) (*Budget, error)
+}
+
+type usageService struct {
+	repo     UsageRepo
+	defaults Quota
+	now      func() time.Time
+}
+
+func NewUsageService(repo UsageRepo) UsageService {
+	return &usageService{repo: repo, defaults: defaultQuota(), now: time.Now}
+}
+
+// defaultQuota reads LLM_DAILY_TOKEN_QUOTA and LLM_DAILY_CALL_QUOTA, where
+// 0 turns the limit off.
+func defaultQuota() Quota {
+	quota := Quota{DailyTokens: defaultDailyTokens, DailyCalls: defaultDailyCalls}
+	if v, err := strconv.Atoi(os.Getenv("LLM_DAILY_TOKEN_QUOTA")); err == nil && v >= 0 {
+		quota.DailyTokens = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("LLM_DAILY_CALL_QUOTA")); err == nil && v >= 0 {
+		quota.DailyCalls = v
+	}
+	return quota
+}
+
+// Allow lets a call through while the user is below both daily limits. The
+// check happens before the call, so the last call of the day may overshoot
+// the token limit by its own size.
+func (s *usageService) Allow(ctx context.Context, userID uint) error {
+	quota, err := s.quota(userID)
+	if err != nil {
+		// accounting trouble must not take the features down with it
+		log.Printf("usage: failed to load budget of user %d: %v", userID, err)
+		return nil
+	}
+	if quota.DailyTokens == 0 && quota.DailyCalls == 0 {
+		return nil
+	}
+	today, err := s.repo.TotalsSince(userID, startOfDay(s.now()))
+	if err != nil {
+		log.Printf("usage: failed to load usage of user %d: %v", userID, err)
+		return nil
+	}
+	if quota.DailyTokens > 0 && today.Tokens >= int64(quota.DailyTokens) {
+		return llm.ErrQuotaExceeded
+	}
+	if quota.DailyCalls > 0 && today.Calls >= int64(quota.DailyCalls) {
+		return llm.ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (s *usageService) Record(ctx context.Context, call llm.Call) {
+	usage := &Usage{
+		Feature:      call.Feature,
+		Model:        call.Model,
+		PromptTokens: call.PromptTokens,
+		OutputTokens: call.OutputTokens,
+		TotalTokens:  call.TotalTokens,
+		LatencyMs:    call.Latency.Milliseconds(),
+		Failed:       call.Err != nil,
+		CreatedAt:    s.now().UTC(),
+	}
+	if call.UserID != 0 {
+		usage.UserID = &call.UserID
+	}
+	if err := s.repo.Create(usage); err != nil {
+		log.Printf("usage: failed to record %s call: %v", call.Feature, err)
+	}
+}
+
+func (s *usageService) Report(userID uint, days int) (*Report, error) {
+	if days < 1 || days > maxReportDays {
+		days = maxReportDays
+	}
+	today := startOfDay(s.now())
+
+	quota, err := s.quota(userID)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.repo.TotalsSince(userID, today)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := s.repo.Daily(userID, today.AddDate(0, 0, 1-days))
+	if err != nil {
+		return nil, err
+	}
+	if daily == nil {
+		daily = []DailyUsage{}
+	}
+	return &Report{
+		Today:    totals,
+		Quota:    quota,
+		ResetsAt: today.AddDate(0, 0, 1),
+		Days:     daily,
+	}, nil
+}
+
+func (s *usageService) SetBudget(userID uint, req BudgetRequest) (*Budget, error) {
+	budget := &Budget{
+		UserID:      userID,
+		DailyTokens: req.DailyTokens,
+		DailyCalls:  req.DailyCalls,
+		UpdatedAt:   s.now().UTC(),
+	}
+	if err := s.repo.SaveBudget(budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// quota is the user's budget on top of the defaults.
+func (s *usageService) quota(userID uint) (Quota, error) {
+	quota := s.defaults
+	budget, err := s.repo.GetBudget(userID)
+	if err != nil || budget == nil {
+		return quota, err
+	}
+	if budget.DailyTokens != nil {
+		quota.DailyTokens = *budget.DailyTokens
+	}
+	if budget.DailyCalls != nil {
+		quota.DailyCalls = *budget.DailyCalls
+	}
+	return quota, nil
+}
+
+// startOfDay is the start of t's UTC day, quotas reset at UTC midnight.
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
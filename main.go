@@ -1,11 +1,28 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"strings"
+	"time"
 	"notemind/database"
+	"notemind/internal/account"
+	"notemind/internal/apikey"
+	"notemind/internal/ask"
 	"notemind/internal/auth"
+	"notemind/internal/digest"
+	"notemind/internal/importer"
 	"notemind/internal/llm"
+	"notemind/internal/mailer"
+	"notemind/internal/media"
 	"notemind/internal/note"
+	"notemind/internal/report"
+	"notemind/internal/review"
+	"notemind/internal/scheduler"
+	"notemind/internal/task"
+	"notemind/internal/token"
+	"notemind/internal/usage"
 	"notemind/internal/voice"
 
 	"github.com/gin-gonic/gin"
@@ -38,34 +55,155 @@ func main() {
 
 	defer llmService.Close()
 
+	imageStore, err := media.NewCloudinaryStore()
+
+	if err != nil {
+		log.Println("failed to init cloudinary")
+	}
+
+	archiveStore, err := media.NewCloudinaryArchiveStore()
+
+	if err != nil {
+		log.Println("failed to init cloudinary archives")
+	}
+
+	tokens, err := token.NewManagerFromEnv()
+
+	if err != nil {
+		log.Panicf("token manager initialization issue: %v", err)
+	}
+
+	mail, err := mailer.NewFromEnv()
+
+	if err != nil {
+		log.Panicf("mailer initialization issue: %v", err)
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 
 	noteRepo := note.NewNoteRepo(db)
-	authRepo := auth.NewAuthRepo(db, llmService)
+	authRepo := auth.NewAuthRepo(db)
+	apiKeyRepo := apikey.NewAPIKeyRepo(db)
+	accountRepo := account.NewAccountRepo(db)
+	digestRepo := digest.NewDigestRepo(db)
+	reportRepo := report.NewReportRepo(db)
+	reviewRepo := review.NewReviewRepo(db)
+	askRepo := ask.NewAskRepo(db)
+	taskRepo := task.NewTaskRepo(db)
+	usageRepo := usage.NewUsageRepo(db)
+	importRepo := importer.NewImportRepo(db)
 
 	//log.Println(authRepo)
 
-	noteService := note.NewNoteService(noteRepo, llmService, voiceClient)
-	authService := auth.NewAuthService(authRepo) 
+	usageService := usage.NewUsageService(usageRepo)
+	llmService.SetMeter(usageService)
+
+	noteService := note.NewNoteService(noteRepo, llmService, voiceClient, imageStore, mail)
+	authService := auth.NewAuthService(authRepo, tokens, mail)
+	apiKeyService := apikey.NewAPIKeyService(apiKeyRepo)
+	accountService := account.NewAccountService(accountRepo, imageStore, archiveStore)
+	digestService := digest.NewDigestService(digestRepo, llmService, digest.NewSender(mail))
+	reportService := report.NewReportService(reportRepo, llmService, mail)
+	reviewService := review.NewReviewService(reviewRepo, llmService)
+	askService := ask.NewAskService(askRepo, llmService)
+	taskService := task.NewTaskService(taskRepo, llmService)
+	importService := importer.NewImportService(importRepo, noteService)
+
+	noteService.OnSave(reviewService)
+	noteService.OnSave(taskService)
+
+	tokens.UseAPIKeys(apiKeyService)
+	tokens.UseAccounts(authService)
+
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), os.Args[1:], digestService, noteService); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 
 
 
 	notehandler := note.NewNoteHandler(noteService)
 	authHandler := auth.NewAuthHandler(authService)
+	apiKeyHandler := apikey.NewAPIKeyHandler(apiKeyService)
+	accountHandler := account.NewAccountHandler(accountService)
+	digestHandler := digest.NewDigestHandler(digestService)
+	reportHandler := report.NewReportHandler(reportService)
+	reviewHandler := review.NewReviewHandler(reviewService)
+	askHandler := ask.NewAskHandler(askService)
+	taskHandler := task.NewTaskHandler(taskService)
+	usageHandler := usage.NewUsageHandler(usageService)
+	importHandler := importer.NewImportHandler(importService)
 
 	router := gin.Default()
+	// ClientIP only believes X-Forwarded-For from the proxies listed in
+	// TRUSTED_PROXIES, addresses or CIDRs separated by commas
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Panicf("invalid TRUSTED_PROXIES: %v", err)
+	}
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:5500","https://noterevive.com"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "message"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-API-Key", "X-Link-Password", "message"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * 60 * 60, // 12 hours
 	}))
 
-	note.SetUpRoutes(router, notehandler)
-	auth.SetUpRoutes(router, authHandler)
+	note.SetUpRoutes(router, notehandler, tokens.Middleware())
+	auth.SetUpRoutes(router, authHandler, tokens.Middleware())
+	apikey.SetUpRoutes(router, apiKeyHandler, tokens.Middleware())
+	account.SetUpRoutes(router, accountHandler, tokens.Middleware())
+	digest.SetUpRoutes(router, digestHandler, tokens.Middleware(), tokens.AdminOrCron())
+	report.SetUpRoutes(router, reportHandler, tokens.Middleware())
+	review.SetUpRoutes(router, reviewHandler, tokens.Middleware())
+	ask.SetUpRoutes(router, askHandler, tokens.Middleware())
+	task.SetUpRoutes(router, taskHandler, tokens.Middleware())
+	usage.SetUpRoutes(router, usageHandler, tokens.Middleware())
+	importer.SetUpRoutes(router, importHandler, tokens.Middleware())
+
+	if err := accountService.FailInterrupted(); err != nil {
+		log.Printf("failed to reset interrupted exports: %v", err)
+	}
+	accountWorker := scheduler.New(db, "account-maintenance", account.WorkerInterval(), accountService.Maintain)
+	go accountWorker.Run(context.Background())
+
+	if err := importService.FailInterrupted(); err != nil {
+		log.Printf("failed to reset interrupted imports: %v", err)
+	}
+	importRecovery := scheduler.New(db, "import-recovery", time.Minute, importService.FailAbandoned)
+	go importRecovery.Run(context.Background())
+
+	if os.Getenv("DIGEST_SCHEDULER_ENABLED") != "false" {
+		interval := 5 * time.Minute
+		if v, err := time.ParseDuration(os.Getenv("DIGEST_SCHEDULER_INTERVAL")); err == nil && v > 0 {
+			interval = v
+		}
+		digestScheduler := scheduler.New(db, "daily-digest", interval, func(ctx context.Context) error {
+			report, err := digestService.Run(ctx)
+			if err != nil {
+				return err
+			}
+			log.Printf("digest run: sent %d, failed %d, skipped %d", report.Sent, report.Failed, report.Skipped)
+			return nil
+		})
+		go digestScheduler.Run(context.Background())
+	}
+
+	// summaries postponed by the AI quota are written once it allows again
+	pendingSummaries := scheduler.New(db, "pending-summaries", 15*time.Minute, func(ctx context.Context) error {
+		_, err := noteService.SummarizePending(ctx, 100)
+		return err
+	})
+	go pendingSummaries.Run(context.Background())
 
 	router.Run(":8080")
 
@@ -0,0 +1 @@
+drop table if EXISTS api_keys;
\ No newline at end of file
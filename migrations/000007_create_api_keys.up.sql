@@ -0,0 +1,15 @@
+create table api_keys (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     name varchar(100) not null,
+     prefix varchar(32) not null,
+     key_hash varchar(64) not null,
+     scopes text not null DEFAULT '',
+     expires_at TIMESTAMPTZ,
+     last_used_at TIMESTAMPTZ,
+     revoked_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create UNIQUE index idx_api_keys_prefix on api_keys(prefix);
+create index idx_api_keys_user_id on api_keys(user_id);
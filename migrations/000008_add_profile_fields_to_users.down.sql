@@ -0,0 +1,8 @@
+drop index if exists idx_users_email_change_token_hash;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS digest_enabled,
+    DROP COLUMN IF EXISTS digest_hour,
+    DROP COLUMN IF EXISTS pending_email,
+    DROP COLUMN IF EXISTS email_change_token_hash,
+    DROP COLUMN IF EXISTS email_change_expires_at;
@@ -0,0 +1,8 @@
+ALTER TABLE users
+    ADD COLUMN digest_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN digest_hour SMALLINT NOT NULL DEFAULT 20,
+    ADD COLUMN pending_email VARCHAR(255),
+    ADD COLUMN email_change_token_hash VARCHAR(64),
+    ADD COLUMN email_change_expires_at TIMESTAMPTZ;
+
+create UNIQUE index idx_users_email_change_token_hash on users(email_change_token_hash);
@@ -0,0 +1,5 @@
+drop table if EXISTS data_exports;
+
+ALTER TABLE note_images DROP COLUMN IF EXISTS public_id;
+
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;
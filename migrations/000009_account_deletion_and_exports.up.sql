@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN deletion_requested_at TIMESTAMPTZ;
+
+ALTER TABLE note_images ADD COLUMN public_id varchar(255);
+
+create table data_exports (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     status varchar(20) not null DEFAULT 'pending',
+     file_path text,
+     error text,
+     expires_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     completed_at TIMESTAMPTZ
+);
+
+create index idx_data_exports_user_id on data_exports(user_id);
@@ -0,0 +1,2 @@
+-- promote operators by hand: update users set role = 'admin' where email = '...';
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
@@ -0,0 +1 @@
+drop table if EXISTS digest_runs;
\ No newline at end of file
@@ -0,0 +1,12 @@
+create table digest_runs (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     local_date date not null,
+     status varchar(20) not null,
+     attempts INTEGER not null DEFAULT 1,
+     error text,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     updated_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create UNIQUE index idx_digest_runs_user_date on digest_runs(user_id, local_date);
@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN digest_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN digest_hour SMALLINT NOT NULL DEFAULT 20;
+
+update users set
+    digest_enabled = p.frequency <> 'off',
+    digest_hour = p.send_hour
+from digest_preferences p
+where p.user_id = users.id;
+
+drop table if EXISTS digest_preferences;
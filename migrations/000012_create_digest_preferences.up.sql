@@ -0,0 +1,17 @@
+create table digest_preferences (
+     user_id INTEGER primary key REFERENCES users(id) on DELETE CASCADE,
+     frequency varchar(10) not null DEFAULT 'daily',
+     send_hour SMALLINT not null DEFAULT 20,
+     weekday SMALLINT not null DEFAULT 0,
+     include_empty BOOLEAN not null DEFAULT TRUE,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     updated_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+insert into digest_preferences (user_id, frequency, send_hour)
+select id, case when digest_enabled then 'daily' else 'off' end, digest_hour
+from users;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS digest_enabled,
+    DROP COLUMN IF EXISTS digest_hour;
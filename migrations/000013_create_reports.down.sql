@@ -0,0 +1 @@
+drop table if EXISTS reports;
\ No newline at end of file
@@ -0,0 +1,17 @@
+create table reports (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     period varchar(10) not null,
+     period_start date not null,
+     period_end date not null,
+     status varchar(20) not null,
+     note_count INTEGER not null DEFAULT 0,
+     retrospective jsonb,
+     error text,
+     email_requested boolean not null DEFAULT false,
+     emailed_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     updated_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create UNIQUE index idx_reports_user_period on reports(user_id, period, period_start);
@@ -0,0 +1 @@
+drop table if EXISTS review_cards;
\ No newline at end of file
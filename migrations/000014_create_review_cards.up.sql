@@ -0,0 +1,18 @@
+create table review_cards (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     note_id INTEGER not null REFERENCES notes(id) on DELETE CASCADE,
+     question text not null,
+     answer text not null,
+     ease_factor DOUBLE PRECISION not null DEFAULT 2.5,
+     interval_days INTEGER not null DEFAULT 0,
+     repetitions INTEGER not null DEFAULT 0,
+     lapses INTEGER not null DEFAULT 0,
+     due_at TIMESTAMPTZ not null DEFAULT NOW(),
+     last_reviewed_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     updated_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create index idx_review_cards_user_due on review_cards(user_id, due_at);
+create index idx_review_cards_note on review_cards(note_id);
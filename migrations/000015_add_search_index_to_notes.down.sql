@@ -0,0 +1 @@
+drop index if EXISTS idx_notes_search;
@@ -0,0 +1 @@
+create index idx_notes_search on notes using gin (to_tsvector('english', title || ' ' || content));
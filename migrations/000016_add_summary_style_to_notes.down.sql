@@ -0,0 +1 @@
+alter table notes drop column if EXISTS summary_style;
\ No newline at end of file
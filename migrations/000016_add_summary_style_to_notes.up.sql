@@ -0,0 +1 @@
+alter table notes add column summary_style varchar(20) not null DEFAULT 'paragraph';
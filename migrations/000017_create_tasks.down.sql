@@ -0,0 +1 @@
+drop table if EXISTS tasks;
\ No newline at end of file
@@ -0,0 +1,15 @@
+create table tasks (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     note_id INTEGER not null REFERENCES notes(id) on DELETE CASCADE,
+     text text not null,
+     assignee varchar(255) not null DEFAULT '',
+     due_date DATE,
+     completed BOOLEAN not null DEFAULT false,
+     completed_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     updated_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create index idx_tasks_user_open_due on tasks(user_id, completed, due_date);
+create index idx_tasks_note on tasks(note_id);
@@ -0,0 +1,3 @@
+drop index if EXISTS idx_notes_user_content_hash;
+alter table notes drop column if EXISTS prompt_version;
+alter table notes drop column if EXISTS content_hash;
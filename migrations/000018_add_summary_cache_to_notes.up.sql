@@ -0,0 +1,4 @@
+alter table notes add column content_hash varchar(64) not null DEFAULT '';
+alter table notes add column prompt_version INTEGER not null DEFAULT 0;
+
+create index idx_notes_user_content_hash on notes(user_id, content_hash);
@@ -0,0 +1,3 @@
+alter table notes drop column if EXISTS summary_status;
+drop table if EXISTS llm_budgets;
+drop table if EXISTS llm_usage;
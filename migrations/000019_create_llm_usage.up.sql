@@ -0,0 +1,23 @@
+create table llm_usage (
+     id bigserial primary key,
+     user_id INTEGER REFERENCES users(id) on DELETE CASCADE,
+     feature varchar(20) not null DEFAULT '',
+     model varchar(100) not null,
+     prompt_tokens INTEGER not null DEFAULT 0,
+     output_tokens INTEGER not null DEFAULT 0,
+     total_tokens INTEGER not null DEFAULT 0,
+     latency_ms INTEGER not null DEFAULT 0,
+     failed BOOLEAN not null DEFAULT false,
+     created_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create index idx_llm_usage_user_created on llm_usage(user_id, created_at);
+
+create table llm_budgets (
+     user_id INTEGER primary key REFERENCES users(id) on DELETE CASCADE,
+     daily_tokens INTEGER,
+     daily_calls INTEGER,
+     updated_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+alter table notes add column summary_status varchar(20) not null DEFAULT 'ready';
@@ -0,0 +1,5 @@
+drop index if EXISTS idx_notes_search;
+create index idx_notes_search on notes using gin (to_tsvector('english', title || ' ' || content));
+
+alter table notes drop column if EXISTS content_text;
+alter table notes drop column if EXISTS content_format;
@@ -0,0 +1,12 @@
+alter table notes add column content_format varchar(20) not null DEFAULT 'plain';
+alter table notes add column content_text text not null DEFAULT '';
+
+-- notes written before formats existed came as plain text or editor HTML
+update notes set content_format = 'html' where content ~ '<[a-zA-Z/][^>]*>';
+update notes set content_text = case
+     when content_format = 'html' then trim(regexp_replace(content, '<[^>]*>', ' ', 'g'))
+     else trim(content)
+end;
+
+drop index if EXISTS idx_notes_search;
+create index idx_notes_search on notes using gin (to_tsvector('english', title || ' ' || content_text));
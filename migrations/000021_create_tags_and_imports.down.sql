@@ -0,0 +1,4 @@
+drop table if EXISTS import_items;
+drop table if EXISTS import_jobs;
+drop table if EXISTS note_tags;
+drop table if EXISTS tags;
@@ -0,0 +1,45 @@
+create table tags (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     name varchar(50) not null,
+     created_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create unique index idx_tags_user_name on tags(user_id, lower(name));
+
+create table note_tags (
+     note_id INTEGER not null REFERENCES notes(id) on DELETE CASCADE,
+     tag_id INTEGER not null REFERENCES tags(id) on DELETE CASCADE,
+     primary key (note_id, tag_id)
+);
+
+create index idx_note_tags_tag_id on note_tags(tag_id);
+
+create table import_jobs (
+     id serial primary key,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     source varchar(20) not null,
+     file_name varchar(255) not null DEFAULT '',
+     file_path text,
+     status varchar(20) not null DEFAULT 'pending',
+     total INTEGER not null DEFAULT 0,
+     imported INTEGER not null DEFAULT 0,
+     skipped INTEGER not null DEFAULT 0,
+     failed INTEGER not null DEFAULT 0,
+     error text,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     completed_at TIMESTAMPTZ
+);
+
+create index idx_import_jobs_user_id on import_jobs(user_id);
+
+create table import_items (
+     id serial primary key,
+     job_id INTEGER not null REFERENCES import_jobs(id) on DELETE CASCADE,
+     name text not null,
+     status varchar(20) not null,
+     note_id INTEGER REFERENCES notes(id) on DELETE SET NULL,
+     message text
+);
+
+create index idx_import_items_job_id on import_items(job_id);
@@ -0,0 +1 @@
+drop table if EXISTS note_shares;
@@ -0,0 +1,12 @@
+create table note_shares (
+     id serial primary key,
+     note_id INTEGER not null REFERENCES notes(id) on DELETE CASCADE,
+     user_id INTEGER not null REFERENCES users(id) on DELETE CASCADE,
+     role varchar(20) not null,
+     invited_by INTEGER REFERENCES users(id) on DELETE SET NULL,
+     created_at TIMESTAMPTZ not null DEFAULT NOW(),
+     updated_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create unique index idx_note_shares_note_user on note_shares(note_id, user_id);
+create index idx_note_shares_user_id on note_shares(user_id);
@@ -0,0 +1,15 @@
+create table note_public_links (
+     id serial primary key,
+     note_id INTEGER not null REFERENCES notes(id) on DELETE CASCADE,
+     token_hash varchar(64) not null,
+     password_hash text,
+     created_by INTEGER REFERENCES users(id) on DELETE SET NULL,
+     expires_at TIMESTAMPTZ,
+     revoked_at TIMESTAMPTZ,
+     view_count INTEGER not null DEFAULT 0,
+     last_viewed_at TIMESTAMPTZ,
+     created_at TIMESTAMPTZ not null DEFAULT NOW()
+);
+
+create unique index idx_note_public_links_token_hash on note_public_links(token_hash);
+create index idx_note_public_links_note_id on note_public_links(note_id);
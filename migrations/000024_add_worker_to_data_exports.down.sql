@@ -0,0 +1,2 @@
+alter table data_exports drop column if EXISTS heartbeat_at;
+alter table data_exports drop column if EXISTS worker;
@@ -0,0 +1,2 @@
+alter table data_exports add column worker varchar(255);
+alter table data_exports add column heartbeat_at TIMESTAMPTZ;
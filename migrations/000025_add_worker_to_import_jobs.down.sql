@@ -0,0 +1,2 @@
+alter table import_jobs drop column if EXISTS heartbeat_at;
+alter table import_jobs drop column if EXISTS worker;
@@ -0,0 +1,2 @@
+alter table import_jobs add column worker varchar(255);
+alter table import_jobs add column heartbeat_at TIMESTAMPTZ;
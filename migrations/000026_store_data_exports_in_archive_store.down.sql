@@ -0,0 +1 @@
+alter table data_exports rename column archive_id to file_path;
@@ -0,0 +1 @@
+alter table data_exports rename column file_path to archive_id;
@@ -0,0 +1 @@
+drop index if exists idx_import_jobs_user_active;
@@ -0,0 +1,4 @@
+update import_jobs set status = 'failed', error = 'import was interrupted, notes listed in its items were imported'
+where status in ('pending', 'processing')
+  and id not in (select max(id) from import_jobs where status in ('pending', 'processing') group by user_id);
+create unique index idx_import_jobs_user_active on import_jobs(user_id) where status in ('pending', 'processing');